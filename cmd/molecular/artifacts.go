@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// artifactsWithClient dispatches the 'artifacts ls' and 'artifacts get'
+// subcommands.
+func artifactsWithClient(args []string, c *molecular.Client, out io.Writer, errOut io.Writer) int {
+	if len(args) < 1 {
+		usage(errOut)
+		return 2
+	}
+	switch args[0] {
+	case "ls":
+		return artifactsListWithClient(args[1:], c, out, errOut)
+	case "get":
+		return artifactsGetWithClient(args[1:], c, out, errOut)
+	default:
+		usage(errOut)
+		return 2
+	}
+}
+
+func artifactsListWithClient(args []string, c *molecular.Client, out io.Writer, errOut io.Writer) int {
+	fs := flag.NewFlagSet("artifacts ls", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	var of outputFlags
+	of.register(fs)
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage(errOut)
+		return 2
+	}
+	taskID := fs.Arg(0)
+	p, err := newPrinter(of, artifactColumns, "size,sha256,path")
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+
+	list, err := c.ListArtifacts(context.Background(), taskID)
+	if err != nil {
+		return reportError(errOut, err)
+	}
+	return p.printList(out, errOut, list)
+}
+
+// artifactColumns are the table columns for 'artifacts ls'.
+var artifactColumns = []column[molecular.Artifact]{
+	{"size", "SIZE", func(a molecular.Artifact) string { return strconv.FormatInt(a.Size, 10) }},
+	{"sha256", "SHA256", func(a molecular.Artifact) string { return shortCommit(a.SHA256) }},
+	{"modified", "MODIFIED", func(a molecular.Artifact) string { return a.ModTime }},
+	{"path", "PATH", func(a molecular.Artifact) string { return a.Path }},
+}
+
+func artifactsGetWithClient(args []string, c *molecular.Client, out io.Writer, errOut io.Writer) int {
+	fs := flag.NewFlagSet("artifacts get", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	var outPath string
+	fs.StringVar(&outPath, "o", "", "write the artifact to this file instead of stdout")
+	_ = fs.Parse(args)
+	if fs.NArg() != 2 {
+		usage(errOut)
+		return 2
+	}
+	taskID, rel := fs.Arg(0), fs.Arg(1)
+
+	body, err := c.GetArtifact(context.Background(), taskID, rel)
+	if err != nil {
+		return reportError(errOut, err)
+	}
+	defer body.Close()
+
+	dst := out
+	if outPath != "" {
+		f, err := os.Create(outPath)
+		if err != nil {
+			fmt.Fprintln(errOut, err.Error())
+			return 1
+		}
+		defer f.Close()
+		dst = f
+	}
+	if _, err := io.Copy(dst, body); err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 1
+	}
+	return 0
+}
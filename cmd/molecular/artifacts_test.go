@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestArtifactsCommands(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/tasks/task-1/artifacts", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"path":"attempts/001-carbon/prompts/carbon.md","size":5,"sha256":"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824","mod_time":""}]`))
+	})
+	mux.HandleFunc("/v1/tasks/task-1/artifacts/attempts/001-carbon/prompts/carbon.md", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	client := &http.Client{}
+
+	out := &bytes.Buffer{}
+	code := run([]string{"artifacts", "ls", "task-1"}, client, ts.URL, out, out)
+	if code != 0 {
+		t.Fatalf("artifacts ls exit code: %d, out=%s", code, out.String())
+	}
+	if !strings.Contains(out.String(), "2cf24dba5fb0") || !strings.Contains(out.String(), "prompts/carbon.md") {
+		t.Fatalf("unexpected ls output: %s", out.String())
+	}
+
+	out.Reset()
+	code = run([]string{"artifacts", "get", "task-1", "attempts/001-carbon/prompts/carbon.md"}, client, ts.URL, out, out)
+	if code != 0 || out.String() != "hello" {
+		t.Fatalf("artifacts get: code=%d out=%q", code, out.String())
+	}
+
+	dst := filepath.Join(t.TempDir(), "prompt.md")
+	out.Reset()
+	code = run([]string{"artifacts", "get", "-o", dst, "task-1", "attempts/001-carbon/prompts/carbon.md"}, client, ts.URL, out, out)
+	if code != 0 {
+		t.Fatalf("artifacts get -o exit code: %d, out=%s", code, out.String())
+	}
+	if b, _ := os.ReadFile(dst); string(b) != "hello" {
+		t.Fatalf("unexpected file contents: %q", string(b))
+	}
+
+	out.Reset()
+	code = run([]string{"artifacts", "get", "task-1", "missing"}, client, ts.URL, out, out)
+	if code != 1 {
+		t.Fatalf("expected exit 1 for missing artifact, got %d", code)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/throw-if-null/molecular/internal/auth"
+	"github.com/throw-if-null/molecular/internal/state"
+)
+
+// envToken names the environment variable holding the API token.
+const envToken = "MOLECULAR_TOKEN"
+
+// credentialsPath returns the file 'auth token create --save' writes and
+// the CLI reads its token from when MOLECULAR_TOKEN is unset.
+func credentialsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "molecular", "credentials"), nil
+}
+
+// loadToken returns the token to send: MOLECULAR_TOKEN, else the contents
+// of the credentials file, else "".
+func loadToken() (string, error) {
+	if tok := os.Getenv(envToken); tok != "" {
+		return tok, nil
+	}
+	p, err := credentialsPath()
+	if err != nil {
+		return "", nil
+	}
+	b, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// authWithIO implements 'auth token create'. Tokens are written hashed to
+// Silicon's state dir, so it must run on the Silicon host as its user.
+func authWithIO(args []string, out io.Writer, errOut io.Writer) int {
+	if len(args) < 2 || args[0] != "token" || args[1] != "create" {
+		usage(errOut)
+		return 2
+	}
+	fs := flag.NewFlagSet("auth token create", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	var name, scope string
+	var save bool
+	fs.StringVar(&name, "name", "", "label for the token")
+	fs.StringVar(&scope, "scope", string(auth.ScopeWrite), "token scope: read or write")
+	fs.BoolVar(&save, "save", false, "also save the token to the CLI credentials file")
+	if err := fs.Parse(args[2:]); err != nil {
+		return 2
+	}
+	sc, err := auth.ParseScope(scope)
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+
+	dir, err := state.Dir()
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 1
+	}
+	plain, tok, err := auth.NewToken(name, sc)
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 1
+	}
+	if err := auth.Add(auth.TokensPath(dir), tok); err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 1
+	}
+
+	if save {
+		p, err := credentialsPath()
+		if err != nil {
+			fmt.Fprintln(errOut, err.Error())
+			return 1
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			fmt.Fprintln(errOut, err.Error())
+			return 1
+		}
+		if err := os.WriteFile(p, []byte(plain+"\n"), 0o600); err != nil {
+			fmt.Fprintln(errOut, err.Error())
+			return 1
+		}
+		fmt.Fprintf(errOut, "saved to %s\n", p)
+	}
+	fmt.Fprintf(errOut, "created token %s (scope %s); it will not be shown again\n", tok.ID, tok.Scope)
+	fmt.Fprintln(out, plain)
+	return 0
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/throw-if-null/molecular/internal/auth"
+	"github.com/throw-if-null/molecular/internal/state"
+)
+
+func TestAuthTokenCreate(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(state.EnvDir, filepath.Join(dir, "state"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("HOME", dir)
+	t.Setenv(envToken, "")
+
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run([]string{"auth", "token", "create", "--name", "ci", "--scope", "read", "--save"}, nil, "", out, errOut)
+	if code != 0 {
+		t.Fatalf("auth token create: exit %d, err=%s", code, errOut.String())
+	}
+	plain := strings.TrimSpace(out.String())
+
+	toks, err := auth.Load(auth.TokensPath(filepath.Join(dir, "state")))
+	if err != nil || len(toks) != 1 {
+		t.Fatalf("expected one stored token, got %v %v", toks, err)
+	}
+	if toks[0].Hash != auth.Hash(plain) || toks[0].Scope != auth.ScopeRead || toks[0].Name != "ci" {
+		t.Fatalf("unexpected stored token: %+v", toks[0])
+	}
+
+	// the saved credentials are picked up and sent as a bearer token
+	var gotAuth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"tasks":[]}`))
+	}))
+	defer ts.Close()
+
+	opts, rest, err := parseGlobal([]string{"--server", ts.URL, "list"}, errOut)
+	if err != nil {
+		t.Fatalf("parse global: %v", err)
+	}
+	client, baseURL, err := newClient(opts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	if code := run(rest, client, baseURL, out, errOut); code != 0 {
+		t.Fatalf("list: exit %d, err=%s", code, errOut.String())
+	}
+	if gotAuth != "Bearer "+plain {
+		t.Fatalf("unexpected Authorization header: %q", gotAuth)
+	}
+
+	// MOLECULAR_TOKEN wins over the credentials file
+	t.Setenv(envToken, "mol_env")
+	opts, _, _ = parseGlobal([]string{"list"}, errOut)
+	if opts.token != "mol_env" {
+		t.Fatalf("expected env token, got %q", opts.token)
+	}
+
+	if code := run([]string{"auth", "token", "create", "--scope", "admin"}, nil, "", out, errOut); code != 2 {
+		t.Fatalf("expected usage error for unknown scope, got %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "config", "molecular", "credentials")); err != nil {
+		t.Fatalf("credentials file: %v", err)
+	}
+}
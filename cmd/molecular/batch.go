@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// batchFlags pick the tasks cancel, cleanup and retry act on when they are
+// not named on the command line.
+type batchFlags struct {
+	selector string
+	status   string
+	all      bool
+	yes      bool
+}
+
+func (b *batchFlags) register(fs *flag.FlagSet, verb string) {
+	fs.StringVar(&b.selector, "selector", "", verb+" every task whose labels match, e.g. epic=billing")
+	fs.StringVar(&b.selector, "l", "", "shorthand for --selector")
+	fs.StringVar(&b.status, "status", "", verb+" every task with this status")
+	fs.BoolVar(&b.all, "all", false, verb+" every task")
+	fs.BoolVar(&b.yes, "yes", false, "do not ask for confirmation")
+	fs.BoolVar(&b.yes, "y", false, "shorthand for --yes")
+}
+
+func (b batchFlags) filtered() bool {
+	return b.selector != "" || b.status != "" || b.all
+}
+
+// targets resolves the task IDs to act on: the ids given, or every task
+// the flags match once the user confirms. A zero code with no IDs means
+// nothing matched; a non-zero code is the exit status.
+func (b batchFlags) targets(ctx context.Context, c *molecular.Client, ids []string, verb string, errOut io.Writer) ([]string, int) {
+	if (len(ids) > 0) == b.filtered() {
+		fmt.Fprintf(errOut, "%s: name task IDs or use --selector, --status or --all\n", verb)
+		return nil, 2
+	}
+	if len(ids) > 0 {
+		return ids, 0
+	}
+	tasks, err := c.ListAllTasks(ctx, molecular.ListTasksOptions{Selector: b.selector, Status: b.status})
+	if err != nil {
+		return nil, reportError(errOut, err)
+	}
+	if len(tasks) == 0 {
+		fmt.Fprintln(errOut, "no matching tasks")
+		return nil, 0
+	}
+	ids = make([]string, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.TaskID
+	}
+	if !b.yes && !confirm(errOut, fmt.Sprintf("%s %d task(s): %s?", verb, len(ids), strings.Join(ids, ", "))) {
+		fmt.Fprintln(errOut, "aborted")
+		return nil, exitError
+	}
+	return ids, 0
+}
+
+// confirm asks a yes/no question on errOut and reads the answer from stdin.
+func confirm(errOut io.Writer, question string) bool {
+	fmt.Fprintf(errOut, "%s [y/N] ", question)
+	line, _ := bufio.NewReader(stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// printBatch prints per-task results and returns the exit code for the
+// first failure, or 0.
+func printBatch(p *printer[molecular.BatchResult], res *molecular.BatchResponse, out, errOut io.Writer) int {
+	if rc := p.printList(out, errOut, res.Results); rc != 0 {
+		return rc
+	}
+	if failed := res.Failed(); len(failed) > 0 {
+		fmt.Fprintf(errOut, "%d of %d task(s) failed\n", len(failed), len(res.Results))
+		return exitCodeFor(failed[0].Error.Code)
+	}
+	return 0
+}
+
+// batchColumns are the table columns for batch results.
+var batchColumns = []column[molecular.BatchResult]{
+	{"id", "TASK ID", func(r molecular.BatchResult) string { return r.TaskID }},
+	{"result", "RESULT", func(r molecular.BatchResult) string {
+		if r.Error != nil {
+			return string(r.Error.Code)
+		}
+		return "ok"
+	}},
+	{"task", "NEW TASK", func(r molecular.BatchResult) string {
+		if r.Task == nil || r.Task.TaskID == r.TaskID {
+			return ""
+		}
+		return r.Task.TaskID
+	}},
+	{"status", "STATUS", func(r molecular.BatchResult) string {
+		if r.Task == nil {
+			return ""
+		}
+		return string(r.Task.Status)
+	}},
+	{"artifacts", "ARTIFACTS REMOVED", func(r molecular.BatchResult) string {
+		if r.Cleanup == nil {
+			return ""
+		}
+		return fmt.Sprint(r.Cleanup.Artifacts)
+	}},
+	{"worktree", "WORKTREE REMOVED", func(r molecular.BatchResult) string {
+		if r.Cleanup == nil {
+			return ""
+		}
+		return fmt.Sprint(r.Cleanup.Worktree)
+	}},
+	{"error", "ERROR", func(r molecular.BatchResult) string {
+		if r.Error == nil {
+			return ""
+		}
+		return r.Error.Message
+	}},
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+func TestBatchCommands(t *testing.T) {
+	var listQuery string
+	var batches []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/tasks", func(w http.ResponseWriter, r *http.Request) {
+		listQuery = r.URL.RawQuery
+		_ = json.NewEncoder(w).Encode(molecular.TaskList{Tasks: []molecular.Task{{TaskID: "a"}, {TaskID: "b"}}})
+	})
+	for _, op := range []string{"batchCancel", "batchCleanup", "batchRetry"} {
+		mux.HandleFunc("POST /v1/tasks:"+op, func(w http.ResponseWriter, r *http.Request) {
+			var req molecular.BatchRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			batches = append(batches, op+" "+strings.Join(req.TaskIDs, ","))
+			var res molecular.BatchResponse
+			for _, id := range req.TaskIDs {
+				switch {
+				case id == "b" && op != "batchCancel":
+					res.Results = append(res.Results, molecular.BatchResult{TaskID: id, Error: &molecular.Error{Code: molecular.CodeTaskRunning, Message: "task is still running"}})
+				case op == "batchCleanup":
+					res.Results = append(res.Results, molecular.BatchResult{TaskID: id, Cleanup: &molecular.CleanupResult{TaskID: id, Artifacts: true}})
+				case op == "batchRetry":
+					res.Results = append(res.Results, molecular.BatchResult{TaskID: id, Task: &molecular.Task{TaskID: id + "-retry-1", Status: "running"}})
+				default:
+					res.Results = append(res.Results, molecular.BatchResult{TaskID: id, Task: &molecular.Task{TaskID: id, Status: "cancelled"}})
+				}
+			}
+			_ = json.NewEncoder(w).Encode(res)
+		})
+	}
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	oldStdin := stdin
+	defer func() { stdin = oldStdin }()
+
+	cli := func(input string, args ...string) (int, string, string) {
+		t.Helper()
+		stdin = strings.NewReader(input)
+		out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+		code := run(args, ts.Client(), ts.URL, out, errOut)
+		return code, out.String(), errOut.String()
+	}
+
+	// selectors list first, ask, then act on exactly the listed IDs
+	code, out, errOut := cli("y\n", "cancel", "-l", "epic=billing", "--no-headers")
+	if code != 0 || listQuery != "selector=epic%3Dbilling&status=running" || batches[0] != "batchCancel a,b" {
+		t.Fatalf("cancel -l: exit %d query=%q batches=%v err=%s", code, listQuery, batches, errOut)
+	}
+	if !strings.Contains(errOut, "cancel 2 task(s): a, b? [y/N]") || !strings.Contains(out, "a  ok") {
+		t.Fatalf("cancel -l: out=%q err=%q", out, errOut)
+	}
+
+	batches = nil
+	if code, _, errOut = cli("n\n", "cleanup", "--status", "completed"); code != exitError || !strings.Contains(errOut, "aborted") || batches != nil {
+		t.Fatalf("declined cleanup: exit %d err=%q batches=%v", code, errOut, batches)
+	}
+
+	// per-task failures are reported and set the exit code
+	code, out, errOut = cli("", "cleanup", "--all", "--yes", "--columns", "id,result,artifacts")
+	if code != exitConflict || !strings.Contains(out, "a        ok            true") || !strings.Contains(out, "b        task_running  -") {
+		t.Fatalf("cleanup --all: exit %d out=%q err=%q", code, out, errOut)
+	}
+	if !strings.Contains(errOut, "1 of 2 task(s) failed") {
+		t.Fatalf("cleanup --all: missing failure summary: %q", errOut)
+	}
+
+	// explicit IDs need no confirmation
+	batches = nil
+	code, out, _ = cli("", "retry", "-o", "jsonpath={[*].task.task_id}", "a", "c")
+	if code != 0 || out != "a-retry-1 c-retry-1" || batches[0] != "batchRetry a,c" {
+		t.Fatalf("retry: exit %d out=%q batches=%v", code, out, batches)
+	}
+
+	for _, args := range [][]string{{"retry"}, {"retry", "--fresh", "a", "c"}, {"retry", "--budget", "carbon=x", "a"}, {"cancel", "--all", "a"}, {"cleanup", "-l", "x=y", "a"}} {
+		if code, _, _ := cli("", args...); code != 2 {
+			t.Fatalf("%v: expected usage error, got %d", args, code)
+		}
+	}
+}
+
+func TestRetryCommand(t *testing.T) {
+	var got molecular.RetryRequest
+	var gotID string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/tasks/{id}/retry", func(w http.ResponseWriter, r *http.Request) {
+		gotID = r.PathValue("id")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(molecular.Task{TaskID: gotID + "-retry-1", Status: "running", RetryOf: gotID, CarbonBudget: got.Budgets["carbon"]})
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run([]string{"retry", "--fresh", "--budget", "carbon=5", "--columns", "id,retry_of,budgets", "t1"}, ts.Client(), ts.URL, out, errOut)
+	if code != 0 || gotID != "t1" || !got.Fresh || got.Budgets["carbon"] != 5 || len(got.Budgets) != 1 {
+		t.Fatalf("retry: exit %d id=%q req=%+v err=%s", code, gotID, got, errOut)
+	}
+	if !strings.Contains(out.String(), "t1-retry-1  t1        carbon=5 helium=0 review=0") {
+		t.Fatalf("unexpected output: %q", out)
+	}
+}
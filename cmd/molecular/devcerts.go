@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/throw-if-null/molecular/internal/devcerts"
+)
+
+// devCertsWithIO implements 'dev-certs', generating a local CA plus
+// server and client certificates for trying out TLS and mTLS offline.
+func devCertsWithIO(args []string, out io.Writer, errOut io.Writer) int {
+	fs := flag.NewFlagSet("dev-certs", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	var dir, hosts string
+	fs.StringVar(&dir, "dir", filepath.Join(".molecular", "certs"), "directory to write certificates to")
+	fs.StringVar(&hosts, "hosts", "localhost,127.0.0.1,::1", "comma-separated DNS names and IPs for the server certificate")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var hs []string
+	for _, h := range strings.Split(hosts, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			hs = append(hs, h)
+		}
+	}
+	if len(hs) == 0 {
+		fmt.Fprintln(errOut, "at least one host is required")
+		return 2
+	}
+	if err := devcerts.Generate(dir, hs); err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 1
+	}
+
+	p := func(name string) string { return filepath.Join(dir, name) }
+	fmt.Fprintf(out, "wrote certificates to %s\n\n", dir)
+	fmt.Fprintln(out, "silicon:")
+	fmt.Fprintf(out, "  silicon --tls-cert %s --tls-key %s --tls-client-ca %s\n", p(devcerts.ServerCert), p(devcerts.ServerKey), p(devcerts.CACert))
+	fmt.Fprintln(out, "molecular:")
+	fmt.Fprintf(out, "  molecular --server https://%s --cacert %s --cert %s --key %s list\n", hs[0]+":8711", p(devcerts.CACert), p(devcerts.ClientCert), p(devcerts.ClientKey))
+	return 0
+}
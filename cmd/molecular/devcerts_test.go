@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/throw-if-null/molecular/internal/devcerts"
+)
+
+func TestDevCertsAndMutualTLSClient(t *testing.T) {
+	t.Setenv(envToken, "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	out := &bytes.Buffer{}
+	if code := run([]string{"dev-certs", "--dir", dir, "--hosts", "127.0.0.1"}, nil, "", out, out); code != 0 {
+		t.Fatalf("dev-certs: exit %d, out=%s", code, out.String())
+	}
+	f := func(name string) string { return filepath.Join(dir, name) }
+
+	pair, err := tls.LoadX509KeyPair(f(devcerts.ServerCert), f(devcerts.ServerKey))
+	if err != nil {
+		t.Fatalf("server pair: %v", err)
+	}
+	pool := x509.NewCertPool()
+	caPEM, _ := os.ReadFile(f(devcerts.CACert))
+	pool.AppendCertsFromPEM(caPEM)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tasks":[{"task_id":"over-mtls"}]}`))
+	}))
+	ts.TLS = &tls.Config{Certificates: []tls.Certificate{pair}, ClientCAs: pool, ClientAuth: tls.RequireAndVerifyClientCert}
+	ts.StartTLS()
+	defer ts.Close()
+
+	args := []string{"--server", ts.URL, "--cacert", f(devcerts.CACert), "--cert", f(devcerts.ClientCert), "--key", f(devcerts.ClientKey), "list"}
+	opts, rest, err := parseGlobal(args, out)
+	if err != nil {
+		t.Fatalf("parse global: %v", err)
+	}
+	client, baseURL, err := newClient(opts)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	out.Reset()
+	if code := run(rest, client, baseURL, out, out); code != 0 {
+		t.Fatalf("list over mTLS: exit %d, out=%s", code, out.String())
+	}
+	if !bytes.Contains(out.Bytes(), []byte("over-mtls")) {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+
+	// without a client certificate the server refuses the handshake
+	client, baseURL, _ = newClient(globalOptions{server: ts.URL, cacert: f(devcerts.CACert)})
+	if code := run([]string{"list"}, client, baseURL, out, out); code != 1 {
+		t.Fatalf("expected failure without client cert, got %d", code)
+	}
+	if _, _, err := newClient(globalOptions{server: ts.URL, cert: f(devcerts.ClientCert)}); err == nil {
+		t.Fatalf("expected error for --cert without --key")
+	}
+}
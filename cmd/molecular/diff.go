@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// diffWithClient implements 'diff', printing the task's worktree changes
+// against its base commit.
+func diffWithClient(args []string, c *molecular.Client, out io.Writer, errOut io.Writer) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	var stat bool
+	fs.BoolVar(&stat, "stat", false, "print a diffstat summary only")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage(errOut)
+		return 2
+	}
+	body, err := c.Diff(context.Background(), fs.Arg(0), molecular.DiffOptions{Stat: stat})
+	return copyBody(body, err, out, errOut)
+}
+
+// patchWithClient implements 'patch', printing the task's commits as an
+// mbox stream suitable for `git am`.
+func patchWithClient(args []string, c *molecular.Client, out io.Writer, errOut io.Writer) int {
+	if len(args) != 1 {
+		usage(errOut)
+		return 2
+	}
+	body, err := c.Diff(context.Background(), args[0], molecular.DiffOptions{Patch: true})
+	return copyBody(body, err, out, errOut)
+}
+
+// copyBody streams a response body to out unchanged, so binary-safe
+// output such as patches can be redirected. err is the error from opening
+// the body.
+func copyBody(body io.ReadCloser, err error, out io.Writer, errOut io.Writer) int {
+	if err != nil {
+		return reportError(errOut, err)
+	}
+	defer body.Close()
+	if _, err := io.Copy(out, body); err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDiffAndPatchCommands(t *testing.T) {
+	var gotQuery string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/tasks/task-1/diff", func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		w.Write([]byte("diff --git a/a.txt b/a.txt\n"))
+	})
+	mux.HandleFunc("/v1/tasks/task-2/diff", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "task has no worktree", http.StatusConflict)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	client := &http.Client{}
+
+	cases := []struct {
+		args  []string
+		query string
+	}{
+		{[]string{"diff", "task-1"}, ""},
+		{[]string{"diff", "--stat", "task-1"}, "stat=true"},
+		{[]string{"patch", "task-1"}, "format=patch"},
+	}
+	for _, c := range cases {
+		out := &bytes.Buffer{}
+		if code := run(c.args, client, ts.URL, out, out); code != 0 {
+			t.Fatalf("%v: exit %d, out=%s", c.args, code, out.String())
+		}
+		if gotQuery != c.query {
+			t.Fatalf("%v: expected query %q, got %q", c.args, c.query, gotQuery)
+		}
+		if out.String() != "diff --git a/a.txt b/a.txt\n" {
+			t.Fatalf("%v: unexpected output %q", c.args, out.String())
+		}
+	}
+
+	out := &bytes.Buffer{}
+	if code := run([]string{"diff", "task-2"}, client, ts.URL, out, out); code != 1 {
+		t.Fatalf("expected exit 1 for task without worktree, got %d", code)
+	}
+}
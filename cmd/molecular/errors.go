@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// Exit codes for failed API requests, on top of the usual 0 (ok),
+// 1 (error) and 2 (usage). They let scripts branch on the failure kind.
+const (
+	exitError      = 1
+	exitNotFound   = 3 // not_found
+	exitConflict   = 4 // task_exists, branch_exists, task_running, no_worktree
+	exitInvalid    = 5 // invalid_request, request_too_large, prompt_failed
+	exitNotRunning = 6 // task_not_running
+	exitAuth       = 7 // unauthorized, forbidden
+	exitBudget     = 8 // budget_exceeded
+)
+
+// exitCodeFor maps an API error code to a CLI exit code.
+func exitCodeFor(code molecular.ErrorCode) int {
+	switch code {
+	case molecular.CodeNotFound:
+		return exitNotFound
+	case molecular.CodeTaskExists, molecular.CodeBranchExists, molecular.CodeTaskRunning, molecular.CodeNoWorktree:
+		return exitConflict
+	case molecular.CodeInvalidRequest, molecular.CodeRequestTooLarge, molecular.CodePromptFailed:
+		return exitInvalid
+	case molecular.CodeTaskNotRunning:
+		return exitNotRunning
+	case molecular.CodeUnauthorized, molecular.CodeForbidden:
+		return exitAuth
+	case molecular.CodeBudgetExceeded:
+		return exitBudget
+	default:
+		return exitError
+	}
+}
+
+// reportError prints err on errOut and returns the exit code for it. API
+// errors show the server's message, code and trace ID.
+func reportError(errOut io.Writer, err error) int {
+	var e *molecular.Error
+	if !errors.As(err, &e) || e.Code == "" {
+		fmt.Fprintln(errOut, err.Error())
+		return exitError
+	}
+	msg := fmt.Sprintf("error: %s (%s)", e.Message, e.Code)
+	if e.TraceID != "" {
+		msg += " trace_id=" + e.TraceID
+	}
+	fmt.Fprintln(errOut, msg)
+	return exitCodeFor(e.Code)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIErrorExitCodes(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		body   string
+		args   []string
+		code   int
+		stderr string
+	}{
+		{"not found", 404, `{"code":"not_found","message":"task not found: nope","trace_id":"abc123"}`,
+			[]string{"status", "nope"}, exitNotFound, "error: task not found: nope (not_found) trace_id=abc123"},
+		{"conflict", 409, `{"code":"task_exists","message":"task already exists"}`,
+			[]string{"submit", "--task-id", "t", "--prompt", "p"}, exitConflict, "(task_exists)"},
+		{"not running", 409, `{"code":"task_not_running","message":"task is not running"}`,
+			[]string{"cancel", "t"}, exitNotRunning, "(task_not_running)"},
+		{"unauthorized", 401, `{"code":"unauthorized","message":"missing bearer token"}`,
+			[]string{"logs", "t"}, exitAuth, "(unauthorized)"},
+		{"budget", 429, `{"code":"budget_exceeded","message":"daily.max_cost_usd cap reached","details":{"cap":"daily.max_cost_usd"}}`,
+			[]string{"submit", "--prompt", "p"}, exitBudget, "error: daily.max_cost_usd cap reached (budget_exceeded)"},
+		{"invalid", 400, `{"code":"invalid_request","message":"bad"}`,
+			[]string{"list"}, exitInvalid, "(invalid_request)"},
+		{"plain text", 502, "bad gateway",
+			[]string{"cleanup", "t"}, exitError, "request failed: 502 Bad Gateway: bad gateway"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer ts.Close()
+
+			errOut := &bytes.Buffer{}
+			code := run(tc.args, ts.Client(), ts.URL, &bytes.Buffer{}, errOut)
+			if code != tc.code {
+				t.Fatalf("exit code: got %d want %d; stderr=%s", code, tc.code, errOut.String())
+			}
+			if !strings.Contains(errOut.String(), tc.stderr) {
+				t.Fatalf("stderr %q does not contain %q", errOut.String(), tc.stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// feedbackWithClient implements 'feedback', adding a note for the next
+// Carbon attempt of a running task. --message - reads the note from stdin.
+func feedbackWithClient(args []string, c *molecular.Client, out io.Writer, errOut io.Writer) int {
+	fs := flag.NewFlagSet("feedback", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	var message, author string
+	fs.StringVar(&message, "message", "", "the note for Carbon, or - to read it from stdin")
+	fs.StringVar(&message, "m", "", "shorthand for --message")
+	fs.StringVar(&author, "author", "", "who the note is from")
+	var of outputFlags
+	of.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	// accept flags after the task ID too: feedback <id> --message ...
+	taskID, rest := fs.Arg(0), fs.Args()
+	if len(rest) > 0 {
+		rest = rest[1:]
+	}
+	if err := fs.Parse(rest); err != nil {
+		return 2
+	}
+	if taskID == "" || fs.NArg() > 0 || message == "" {
+		usage(errOut)
+		return 2
+	}
+	if message == "-" {
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintln(errOut, err.Error())
+			return 1
+		}
+		message = string(b)
+	}
+	if strings.TrimSpace(message) == "" {
+		fmt.Fprintln(errOut, "feedback: empty message")
+		return 2
+	}
+	p, err := newPrinter(of, taskColumns, "id,status,phase,feedback")
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+
+	t, err := c.AddFeedback(context.Background(), taskID, molecular.FeedbackRequest{Message: message, Author: author})
+	if err != nil {
+		return reportError(errOut, err)
+	}
+	return p.printOne(out, errOut, *t)
+}
+
+// feedbackSummary counts a task's feedback notes and those still waiting
+// for a Carbon attempt.
+func feedbackSummary(t molecular.Task) string {
+	if len(t.Feedback) == 0 {
+		return ""
+	}
+	pending := 0
+	for _, f := range t.Feedback {
+		if f.Pending() {
+			pending++
+		}
+	}
+	return fmt.Sprintf("%d (%d pending)", len(t.Feedback), pending)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+func TestFeedbackCommand(t *testing.T) {
+	var got molecular.FeedbackRequest
+	var gotID string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/tasks/{id}/feedback", func(w http.ResponseWriter, r *http.Request) {
+		gotID, got = r.PathValue("id"), molecular.FeedbackRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_ = json.NewEncoder(w).Encode(molecular.Task{TaskID: gotID, Status: "running", Phase: "executing", Feedback: []molecular.Feedback{
+			{Message: "earlier", Attempt: 1}, {Message: got.Message, Author: got.Author},
+		}})
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	oldStdin := stdin
+	defer func() { stdin = oldStdin }()
+
+	cli := func(input string, args ...string) (int, string) {
+		t.Helper()
+		stdin = strings.NewReader(input)
+		out := &bytes.Buffer{}
+		return run(args, ts.Client(), ts.URL, out, io.Discard), out.String()
+	}
+
+	code, out := cli("", "feedback", "t1", "--message", "keep the v1 endpoint", "--author", "ana", "--no-headers")
+	if code != 0 || gotID != "t1" || got.Message != "keep the v1 endpoint" || got.Author != "ana" {
+		t.Fatalf("feedback: exit %d id=%q req=%+v", code, gotID, got)
+	}
+	if out != "t1  running  executing  2 (1 pending)\n" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+	if code, _ := cli("see the diff\n", "feedback", "-m", "-", "t1"); code != 0 || got.Message != "see the diff\n" || got.Author != "" {
+		t.Fatalf("feedback from stdin: exit %d req=%+v", code, got)
+	}
+	for _, args := range [][]string{{"feedback", "t1"}, {"feedback", "-m", "x"}, {"feedback", "t1", "t2", "-m", "x"}} {
+		if code, _ := cli("", args...); code != 2 {
+			t.Fatalf("%v: expected usage error, got %d", args, code)
+		}
+	}
+	if code, _ := cli(" \n", "feedback", "-m", "-", "t1"); code != 2 {
+		t.Fatalf("empty stdin: expected usage error, got %d", code)
+	}
+}
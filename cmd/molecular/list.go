@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// listWithClient implements 'list', printing tasks newest first unless
+// --sort says otherwise.
+func listWithClient(args []string, c *molecular.Client, out io.Writer, errOut io.Writer) int {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	var opts molecular.ListTasksOptions
+	var since, until string
+	var all bool
+	var of outputFlags
+	of.register(fs)
+	fs.StringVar(&opts.Status, "status", "", "only tasks with this status")
+	fs.StringVar(&opts.Phase, "phase", "", "only tasks in this phase")
+	fs.StringVar(&opts.Prefix, "prefix", "", "only task IDs starting with this prefix")
+	fs.StringVar(&opts.Selector, "selector", "", "only tasks whose labels match, e.g. epic=billing,owner!=bob")
+	fs.StringVar(&opts.Selector, "l", "", "shorthand for --selector")
+	labels := varsFlag{}
+	fs.Var(labels, "label", "only tasks with this label value, k=v (repeatable)")
+	fs.StringVar(&since, "since", "", "only tasks created at or after this RFC 3339 time or duration ago (e.g. 2h)")
+	fs.StringVar(&until, "until", "", "only tasks created before this RFC 3339 time or duration ago")
+	fs.StringVar(&opts.Sort, "sort", "", "created_at or updated_at, '-' prefix for descending (default -created_at)")
+	fs.IntVar(&opts.Limit, "limit", 0, "page size")
+	fs.StringVar(&opts.Cursor, "cursor", "", "continue from a previous page's cursor")
+	fs.BoolVar(&all, "all", false, "fetch every page")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if len(labels) > 0 {
+		opts.Labels = labels
+	}
+	p, err := newPrinter(of, taskColumns, taskListColumns)
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+	if opts.Since, err = parseTimeFlag(since, time.Now()); err != nil {
+		fmt.Fprintf(errOut, "--since: %v\n", err)
+		return 2
+	}
+	if opts.Until, err = parseTimeFlag(until, time.Now()); err != nil {
+		fmt.Fprintf(errOut, "--until: %v\n", err)
+		return 2
+	}
+
+	ctx := context.Background()
+	var page molecular.TaskList
+	if all {
+		page.Tasks, err = c.ListAllTasks(ctx, opts)
+	} else {
+		var p *molecular.TaskList
+		if p, err = c.ListTasks(ctx, opts); err == nil {
+			page = *p
+		}
+	}
+	if err != nil {
+		return reportError(errOut, err)
+	}
+	if page.NextCursor != "" {
+		fmt.Fprintf(errOut, "more tasks: --cursor %s\n", page.NextCursor)
+	}
+	return p.printList(out, errOut, page.Tasks)
+}
+
+// parseTimeFlag accepts an RFC 3339 time or a duration before now, which
+// may count days as in 7d. An empty value yields the zero time.
+func parseTimeFlag(v string, now time.Time) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(-d), nil
+	}
+	if n, ok := strings.CutSuffix(v, "d"); ok {
+		if days, err := strconv.Atoi(n); err == nil && days >= 0 {
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("want an RFC 3339 time or a duration like 2h or 7d, got %q", v)
+	}
+	return t, nil
+}
+
+// summarize returns the first line of s, cut to n runes.
+func summarize(s string, n int) string {
+	s, _, _ = strings.Cut(s, "\n")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+func TestListTableAndFilters(t *testing.T) {
+	var queries []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		page := molecular.TaskList{Tasks: []molecular.Task{{
+			TaskID: "fix-login-a1b2c3", Status: "running", Phase: "executing",
+			CreatedAt: "2026-01-02T10:00:00Z", Prompt: "Fix the login redirect loop\nsecond line",
+		}}}
+		if r.URL.Query().Get("cursor") == "" {
+			page.NextCursor = "c1"
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	defer ts.Close()
+
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	args := []string{"list", "--status", "running", "--prefix", "fix-", "--since", "2026-01-01T00:00:00Z", "--sort", "created_at", "--limit", "1"}
+	if code := run(args, ts.Client(), ts.URL, out, errOut); code != 0 {
+		t.Fatalf("list: exit %d, err=%s", code, errOut.String())
+	}
+	if queries[0] != "limit=1&prefix=fix-&since=2026-01-01T00%3A00%3A00Z&sort=created_at&status=running" {
+		t.Fatalf("unexpected query: %s", queries[0])
+	}
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "TASK ID") ||
+		!strings.Contains(lines[1], "fix-login-a1b2c3") || !strings.HasSuffix(lines[1], "Fix the login redirect loop") {
+		t.Fatalf("unexpected table:\n%s", out.String())
+	}
+	if !strings.Contains(errOut.String(), "--cursor c1") {
+		t.Fatalf("missing next page hint: %q", errOut.String())
+	}
+
+	// --all follows the cursor
+	queries = nil
+	out.Reset()
+	if code := run([]string{"list", "--all", "--limit", "1", "--json"}, ts.Client(), ts.URL, out, errOut); code != 0 {
+		t.Fatalf("list --all: exit %d", code)
+	}
+	var tasks []molecular.Task
+	if err := json.Unmarshal(out.Bytes(), &tasks); err != nil || len(tasks) != 2 || len(queries) != 2 {
+		t.Fatalf("list --all: %v %d tasks over %d requests", err, len(tasks), len(queries))
+	}
+
+	if code := run([]string{"list", "--since", "last week"}, ts.Client(), ts.URL, out, errOut); code != 2 {
+		t.Fatalf("expected usage error for bad --since, got %d", code)
+	}
+}
+
+func TestParseTimeFlag(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	if got, err := parseTimeFlag("2h", now); err != nil || !got.Equal(now.Add(-2*time.Hour)) {
+		t.Fatalf("duration: %v %v", got, err)
+	}
+	if got, err := parseTimeFlag("2026-01-01T00:00:00Z", now); err != nil || got.Day() != 1 {
+		t.Fatalf("rfc3339: %v %v", got, err)
+	}
+	if got, err := parseTimeFlag("7d", now); err != nil || !got.Equal(now.AddDate(0, 0, -7)) {
+		t.Fatalf("days: %v %v", got, err)
+	}
+	if got, err := parseTimeFlag("", now); err != nil || !got.IsZero() {
+		t.Fatalf("empty: %v %v", got, err)
+	}
+	if _, err := parseTimeFlag("-1d", now); err == nil {
+		t.Fatalf("expected error for negative days")
+	}
+}
+
+func TestLabelSelectors(t *testing.T) {
+	var created molecular.CreateTaskRequest
+	var listQuery string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/tasks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&created)
+		_ = json.NewEncoder(w).Encode(molecular.Task{TaskID: "t1", Labels: created.Labels})
+	})
+	mux.HandleFunc("GET /v1/tasks", func(w http.ResponseWriter, r *http.Request) {
+		listQuery = r.URL.RawQuery
+		_ = json.NewEncoder(w).Encode(molecular.TaskList{Tasks: []molecular.Task{{TaskID: "t1", Labels: map[string]string{"epic": "billing", "owner": "ana"}}}})
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	args := []string{"submit", "--prompt", "p", "--label", "epic=billing", "--label", "owner=ana", "--meta", "ticket=BIL-12", "-o", "jsonpath={.labels.epic}"}
+	if code := run(args, ts.Client(), ts.URL, out, errOut); code != 0 || out.String() != "billing" {
+		t.Fatalf("submit: exit %d out=%q err=%s", code, out.String(), errOut.String())
+	}
+	if created.Labels["owner"] != "ana" || created.Metadata["ticket"] != "BIL-12" {
+		t.Fatalf("unexpected create request: %+v", created)
+	}
+
+	out.Reset()
+	if code := run([]string{"list", "-l", "epic=billing", "--no-headers", "--columns", "id,labels"}, ts.Client(), ts.URL, out, errOut); code != 0 {
+		t.Fatalf("list -l: exit %d err=%s", code, errOut.String())
+	}
+	if listQuery != "selector=epic%3Dbilling" || out.String() != "t1  epic=billing,owner=ana\n" {
+		t.Fatalf("list -l: query=%q out=%q", listQuery, out.String())
+	}
+
+	out.Reset()
+	if code := run([]string{"list", "--label", "owner=ana", "--label", "epic=billing", "--no-headers", "--columns", "id"}, ts.Client(), ts.URL, out, errOut); code != 0 {
+		t.Fatalf("list --label: exit %d err=%s", code, errOut.String())
+	}
+	if listQuery != "label=epic%3Dbilling&label=owner%3Dana" {
+		t.Fatalf("list --label: query=%q", listQuery)
+	}
+}
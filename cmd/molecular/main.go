@@ -1,8 +1,9 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -10,11 +11,14 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"time"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/throw-if-null/molecular/internal/api"
 	"github.com/throw-if-null/molecular/internal/version"
+	"github.com/throw-if-null/molecular/pkg/molecular"
 )
 
 func main() {
@@ -22,25 +26,58 @@ func main() {
 		fmt.Fprintf(os.Stderr, "warning: loading .env: %v\n", err)
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	baseURL := fmt.Sprintf("http://%s:%d", api.DefaultHost, api.DefaultPort)
-	os.Exit(run(os.Args[1:], client, baseURL, os.Stdout, os.Stderr))
+	opts, args, err := parseGlobal(os.Args[1:], os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+	client, baseURL, err := newClient(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+	os.Exit(run(args, client, baseURL, os.Stdout, os.Stderr))
 }
 
 // execLookPath is a variable to allow tests to stub out LookPath.
 var execLookPath = func(name string) (string, error) { return exec.LookPath(name) }
 
 func usage(w io.Writer) {
-	_, _ = fmt.Fprintln(w, "usage:")
-	_, _ = fmt.Fprintln(w, "  molecular submit --task-id <id> --prompt <text>")
+	_, _ = fmt.Fprintln(w, "usage: molecular [--server addr] <command> [args]")
+	_, _ = fmt.Fprintln(w, "")
+	_, _ = fmt.Fprintln(w, "  --server  Silicon address: host:port, http://host:port or unix:///path/silicon.sock")
+	_, _ = fmt.Fprintf(w, "            (env %s, default %s)\n", envServer, api.DefaultAddress())
+	_, _ = fmt.Fprintln(w, "  --cacert, --cert, --key  PEM files for HTTPS and mTLS (env MOLECULAR_CACERT, MOLECULAR_CERT, MOLECULAR_KEY)")
+	_, _ = fmt.Fprintf(w, "  token     sent as a bearer token from %s or the credentials file\n", envToken)
+	_, _ = fmt.Fprintln(w, "")
+	_, _ = fmt.Fprintln(w, "commands:")
+	_, _ = fmt.Fprintln(w, "  molecular submit [--task-id <id>] (--prompt <text|-> | --prompt-file <path> | --template <name> [--var k=v]...)")
+	_, _ = fmt.Fprintln(w, "                   [--repo path] [--base ref] [--branch name] [--if-not-exists] [--idempotency-key key]")
+	_, _ = fmt.Fprintln(w, "                   [--label k=v]... [--meta k=v]...")
 	_, _ = fmt.Fprintln(w, "  molecular status <task-id>")
-	_, _ = fmt.Fprintln(w, "  molecular list [--limit N]")
-	_, _ = fmt.Fprintln(w, "  molecular cancel <task-id>")
-	_, _ = fmt.Fprintln(w, "  molecular logs <task-id> [--tail N]")
-	_, _ = fmt.Fprintln(w, "  molecular cleanup <task-id>")
+	_, _ = fmt.Fprintln(w, "  molecular list [-l selector] [--label k=v]... [--status s] [--phase p] [--prefix id] [--since t] [--until t] [--sort key]")
+	_, _ = fmt.Fprintln(w, "                 [--limit N] [--cursor c | --all]")
+	_, _ = fmt.Fprintln(w, "  molecular cancel (<task-id>... | -l selector | --status s | --all) [-y|--yes]")
+	_, _ = fmt.Fprintln(w, "  molecular retry <task-id> [--fresh] [--budget role=N]...")
+	_, _ = fmt.Fprintln(w, "  molecular retry (<task-id>... | -l selector | --status s | --all) [-y|--yes]")
+	_, _ = fmt.Fprintln(w, "  molecular feedback <task-id> --message <text|-> [--author name]")
+	_, _ = fmt.Fprintln(w, "  molecular usage [--since t] [--until t] [-l selector] [--by model|task|status|day|label|label:<key>]")
+	_, _ = fmt.Fprintln(w, "  molecular logs <task-id> [--tail N] [-f|--follow]")
+	_, _ = fmt.Fprintln(w, "  molecular cleanup (<task-id>... | -l selector | --status s | --all) [-y|--yes]")
+	_, _ = fmt.Fprintln(w, "  molecular diff <task-id> [--stat]")
+	_, _ = fmt.Fprintln(w, "  molecular patch <task-id>")
+	_, _ = fmt.Fprintln(w, "  molecular artifacts ls <task-id>")
+	_, _ = fmt.Fprintln(w, "  molecular artifacts get <task-id> <path> [-o file]")
+	_, _ = fmt.Fprintln(w, "  molecular auth token create [--name n] [--scope read|write] [--save]")
+	_, _ = fmt.Fprintln(w, "  molecular dev-certs [--dir .molecular/certs] [--hosts localhost,127.0.0.1,::1]")
 	_, _ = fmt.Fprintln(w, "  molecular version")
 	_, _ = fmt.Fprintln(w, "  molecular doctor [--json]")
 	_, _ = fmt.Fprintln(w, "")
+	_, _ = fmt.Fprintln(w, "output flags (submit, status, list, cancel, cleanup, retry, feedback, usage, artifacts ls):")
+	_, _ = fmt.Fprintln(w, "  -o, --output  table (default), json, yaml, jsonpath=<expr> or go-template=<tpl>; --json = -o json")
+	_, _ = fmt.Fprintln(w, "  --columns     comma-separated table columns, e.g. id,status,branch")
+	_, _ = fmt.Fprintln(w, "  --no-headers  omit the table header row")
+	_, _ = fmt.Fprintln(w, "")
 	_, _ = fmt.Fprintln(w, "doctor checks:")
 	_, _ = fmt.Fprintln(w, "  - git in PATH (required)")
 	_, _ = fmt.Fprintln(w, "  - gh in PATH (optional)")
@@ -49,34 +86,57 @@ func usage(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "")
 	_, _ = fmt.Fprintln(w, "exit codes:")
 	_, _ = fmt.Fprintln(w, "  0: ok")
-	_, _ = fmt.Fprintln(w, "  1: problems found")
+	_, _ = fmt.Fprintln(w, "  1: error (doctor: problems found)")
 	_, _ = fmt.Fprintln(w, "  2: usage error")
+	_, _ = fmt.Fprintln(w, "  3: not found")
+	_, _ = fmt.Fprintln(w, "  4: conflict (task or branch exists, task still running, no worktree)")
+	_, _ = fmt.Fprintln(w, "  5: invalid request")
+	_, _ = fmt.Fprintln(w, "  6: task not running")
+	_, _ = fmt.Fprintln(w, "  7: unauthorized or forbidden")
+	_, _ = fmt.Fprintln(w, "  8: budget exceeded")
 }
 
 // run executes the CLI logic and returns an exit code.
-func run(args []string, client *http.Client, baseURL string, out io.Writer, errOut io.Writer) int {
+func run(args []string, hc *http.Client, baseURL string, out io.Writer, errOut io.Writer) int {
 	if len(args) < 1 {
 		usage(errOut)
 		return 2
 	}
+	c := molecular.New(baseURL, hc)
 	switch args[0] {
 	case "submit":
-		return submitWithClient(args[1:], client, baseURL, out, errOut)
+		return submitWithClient(args[1:], c, out, errOut)
 	case "status":
-		return statusWithClient(args[1:], client, baseURL, out, errOut)
+		return statusWithClient(args[1:], c, out, errOut)
 	case "list":
-		return listWithClient(args[1:], client, baseURL, out, errOut)
+		return listWithClient(args[1:], c, out, errOut)
 	case "cancel":
-		return cancelWithClient(args[1:], client, baseURL, out, errOut)
+		return cancelWithClient(args[1:], c, out, errOut)
+	case "retry":
+		return retryWithClient(args[1:], c, out, errOut)
+	case "feedback":
+		return feedbackWithClient(args[1:], c, out, errOut)
+	case "usage":
+		return usageWithClient(args[1:], c, out, errOut)
 	case "logs":
-		return logsWithClient(args[1:], client, baseURL, out, errOut)
+		return logsWithClient(args[1:], c, out, errOut)
 	case "cleanup":
-		return cleanupWithClient(args[1:], client, baseURL, out, errOut)
+		return cleanupWithClient(args[1:], c, out, errOut)
+	case "diff":
+		return diffWithClient(args[1:], c, out, errOut)
+	case "patch":
+		return patchWithClient(args[1:], c, out, errOut)
+	case "artifacts":
+		return artifactsWithClient(args[1:], c, out, errOut)
 	case "version":
 		fmt.Fprintf(out, "molecular %s (%s)\n", version.Version, version.Commit)
 		return 0
 	case "doctor":
 		return doctorWithIO(args[1:], out, errOut)
+	case "auth":
+		return authWithIO(args[1:], out, errOut)
+	case "dev-certs":
+		return devCertsWithIO(args[1:], out, errOut)
 	default:
 		usage(errOut)
 		return 2
@@ -84,215 +144,374 @@ func run(args []string, client *http.Client, baseURL string, out io.Writer, errO
 }
 
 // submitWithClient implements submit using provided http client/baseURL.
-func submitWithClient(args []string, client *http.Client, baseURL string, out io.Writer, errOut io.Writer) int {
+func submitWithClient(args []string, c *molecular.Client, out io.Writer, errOut io.Writer) int {
 	fs := flag.NewFlagSet("submit", flag.ContinueOnError)
 	fs.SetOutput(errOut)
 	var taskID string
-	var prompt string
-	fs.StringVar(&taskID, "task-id", "", "task id")
-	fs.StringVar(&prompt, "prompt", "", "task prompt")
+	var prompt, promptFile, tmpl string
+	var repo, base, branch string
+	var ifNotExists bool
+	var idemKey string
+	vars := varsFlag{}
+	labels, metadata := varsFlag{}, varsFlag{}
+	var of outputFlags
+	of.register(fs)
+	fs.StringVar(&taskID, "task-id", "", "task id (default: generated from the prompt)")
+	fs.StringVar(&prompt, "prompt", "", "task prompt ('-' reads stdin)")
+	fs.StringVar(&promptFile, "prompt-file", "", "read the task prompt from a file")
+	fs.StringVar(&tmpl, "template", "", "render the prompt from .molecular/templates/<name>.tmpl")
+	fs.Var(vars, "var", "template variable k=v (repeatable)")
+	fs.Var(labels, "label", "task label k=v for grouping and selectors (repeatable)")
+	fs.Var(metadata, "meta", "free-form task metadata k=v (repeatable)")
+	fs.BoolVar(&ifNotExists, "if-not-exists", false, "return the existing task instead of failing when --task-id exists")
+	fs.StringVar(&idemKey, "idempotency-key", "", "retry-safe key: resubmits with the same key return the original task")
+	fs.StringVar(&repo, "repo", "", "git repository to create the task worktree in (default: current directory when --base or --branch is set)")
+	fs.StringVar(&base, "base", "", "ref to start the task worktree from (default HEAD)")
+	fs.StringVar(&branch, "branch", "", "branch to create for the task (default molecular/<task-id>)")
 	_ = fs.Parse(args)
-
-	if taskID == "" || prompt == "" {
-		fs.Usage()
+	p, err := newPrinter(of, taskColumns, taskDetailColumns)
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
 		return 2
 	}
 
-	req := api.CreateTaskRequest{TaskID: taskID, Prompt: prompt}
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(&req); err != nil {
-		fmt.Fprintln(errOut, err.Error())
-		return 1
+	if ifNotExists && taskID == "" {
+		fmt.Fprintln(errOut, "--if-not-exists requires --task-id")
+		return 2
 	}
-
-	resp, err := client.Post(baseURL+"/v1/tasks", "application/json", &buf)
+	text, meta, err := resolvePrompt(prompt, promptFile, tmpl, vars)
 	if err != nil {
 		fmt.Fprintln(errOut, err.Error())
+		if errors.Is(err, errPromptSource) {
+			return 2
+		}
 		return 1
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Fprintln(errOut, err.Error())
-		return 1
+	// Silicon may run with a different working directory, so always send
+	// an absolute repository path.
+	if repo == "" && (base != "" || branch != "") {
+		repo = "."
 	}
-	if resp.StatusCode >= 400 {
-		fmt.Fprintln(errOut, fmt.Errorf("request failed: %s: %s", resp.Status, string(body)).Error())
-		return 1
+	if repo != "" {
+		abs, err := filepath.Abs(repo)
+		if err != nil {
+			fmt.Fprintln(errOut, err.Error())
+			return 1
+		}
+		repo = abs
 	}
 
-	fmt.Fprintln(out, string(body))
-	return 0
+	req := molecular.CreateTaskRequest{
+		TaskID:         taskID,
+		Prompt:         text,
+		RepoPath:       repo,
+		BaseRef:        base,
+		BranchName:     branch,
+		PromptTemplate: meta,
+		IfNotExists:    ifNotExists,
+		Labels:         labels,
+		Metadata:       metadata,
+	}
+	t, err := c.CreateTask(context.Background(), req, molecular.CreateTaskOptions{IdempotencyKey: idemKey})
+	if err != nil {
+		return reportError(errOut, err)
+	}
+	return p.printOne(out, errOut, *t)
 }
 
 // statusWithClient implements status using provided http client/baseURL.
-func statusWithClient(args []string, client *http.Client, baseURL string, out io.Writer, errOut io.Writer) int {
+func statusWithClient(args []string, c *molecular.Client, out io.Writer, errOut io.Writer) int {
 	fs := flag.NewFlagSet("status", flag.ContinueOnError)
 	fs.SetOutput(errOut)
-	var jsonMode bool
-	fs.BoolVar(&jsonMode, "json", false, "output compact JSON for scripting")
+	var of outputFlags
+	of.register(fs)
 	_ = fs.Parse(args)
 	if fs.NArg() != 1 {
 		usage(errOut)
 		return 2
 	}
 	taskID := fs.Arg(0)
-
-	resp, err := client.Get(baseURL + "/v1/tasks/" + taskID)
+	p, err := newPrinter(of, taskColumns, taskDetailColumns)
 	if err != nil {
 		fmt.Fprintln(errOut, err.Error())
-		return 1
+		return 2
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	t, err := c.GetTask(context.Background(), taskID)
 	if err != nil {
-		fmt.Fprintln(errOut, err.Error())
-		return 1
-	}
-	if resp.StatusCode >= 400 {
-		fmt.Fprintln(errOut, fmt.Errorf("request failed: %s: %s", resp.Status, string(body)).Error())
-		return 1
+		return reportError(errOut, err)
 	}
+	return p.printOne(out, errOut, *t)
+}
 
-	if jsonMode {
-		fmt.Fprintln(out, string(body))
-		return 0
-	}
+// Default table columns for a single task and for task lists.
+const (
+	taskDetailColumns = "id,status,phase,attempt,branch,base,budgets,labels,retry_of,tokens,cost"
+	taskListColumns   = "id,status,phase,created,labels,cost,prompt"
+)
 
-	// pretty print concise human-friendly status
-	var t api.Task
-	if err := json.Unmarshal(body, &t); err != nil {
-		fmt.Fprintln(errOut, err.Error())
-		return 1
-	}
-	// one-line summary
-	fmt.Fprintf(out, "%s  phase=%s  status=%s\n", t.TaskID, t.Phase, t.Status)
-	if t.LatestAttempt != nil {
-		fmt.Fprintf(out, "latest attempt: id=%d role=%s status=%s\n", t.LatestAttempt.ID, t.LatestAttempt.Role, t.LatestAttempt.Status)
+// taskColumns are the table columns available for tasks.
+var taskColumns = []column[molecular.Task]{
+	{"id", "TASK ID", func(t molecular.Task) string { return t.TaskID }},
+	{"status", "STATUS", func(t molecular.Task) string { return string(t.Status) }},
+	{"phase", "PHASE", func(t molecular.Task) string { return t.Phase }},
+	{"attempt", "ATTEMPT", func(t molecular.Task) string {
+		if a := t.LatestAttempt; a != nil {
+			return fmt.Sprintf("%d %s %s", a.ID, a.Role, a.Status)
+		}
+		return ""
+	}},
+	{"branch", "BRANCH", func(t molecular.Task) string { return t.BranchName }},
+	{"base", "BASE", func(t molecular.Task) string {
+		if t.BaseCommit == "" {
+			return t.BaseRef
+		}
+		return fmt.Sprintf("%s@%s", t.BaseRef, shortCommit(t.BaseCommit))
+	}},
+	{"worktree", "WORKTREE", func(t molecular.Task) string { return t.WorktreePath }},
+	{"template", "TEMPLATE", func(t molecular.Task) string {
+		if t.PromptTemplate == nil {
+			return ""
+		}
+		return t.PromptTemplate.Name
+	}},
+	{"budgets", "BUDGETS", func(t molecular.Task) string {
+		return fmt.Sprintf("carbon=%d helium=%d review=%d", t.CarbonBudget, t.HeliumBudget, t.ReviewBudget)
+	}},
+	{"labels", "LABELS", func(t molecular.Task) string { return varsFlag(t.Labels).String() }},
+	{"feedback", "FEEDBACK", feedbackSummary},
+	{"retry_of", "RETRY OF", func(t molecular.Task) string { return t.RetryOf }},
+	{"tokens", "TOKENS", func(t molecular.Task) string {
+		if t.Usage == nil {
+			return ""
+		}
+		return formatTokens(*t.Usage)
+	}},
+	{"cost", "COST", func(t molecular.Task) string {
+		if t.Usage == nil {
+			return ""
+		}
+		return formatCost(t.Usage.CostUSD)
+	}},
+	{"error", "ERROR", func(t molecular.Task) string {
+		if t.Error == nil {
+			return ""
+		}
+		return t.Error.Message
+	}},
+	{"created", "CREATED", func(t molecular.Task) string { return t.CreatedAt }},
+	{"updated", "UPDATED", func(t molecular.Task) string { return t.UpdatedAt }},
+	{"prompt", "PROMPT", func(t molecular.Task) string { return summarize(t.Prompt, 50) }},
+}
+
+// shortCommit abbreviates a commit hash for display.
+func shortCommit(c string) string {
+	if len(c) > 12 {
+		return c[:12]
 	}
-	// print retry budgets/counters
-	fmt.Fprintf(out, "budgets: carbon=%d helium=%d review=%d\n", t.CarbonBudget, t.HeliumBudget, t.ReviewBudget)
-	return 0
+	return c
 }
 
-func listWithClient(args []string, client *http.Client, baseURL string, out io.Writer, errOut io.Writer) int {
-	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+// cancelWithClient cancels one task, or several at once through the
+// batch endpoint when given more IDs or selection flags.
+func cancelWithClient(args []string, c *molecular.Client, out io.Writer, errOut io.Writer) int {
+	fs := flag.NewFlagSet("cancel", flag.ContinueOnError)
 	fs.SetOutput(errOut)
-	var limit int
-	fs.IntVar(&limit, "limit", 0, "limit number of tasks")
-	_ = fs.Parse(args)
-
-	url := baseURL + "/v1/tasks"
-	if limit > 0 {
-		url = fmt.Sprintf("%s?limit=%d", url, limit)
+	var of outputFlags
+	of.register(fs)
+	var bf batchFlags
+	bf.register(fs, "cancel")
+	if err := fs.Parse(args); err != nil {
+		return 2
 	}
-
-	resp, err := client.Get(url)
-	if err != nil {
-		fmt.Fprintln(errOut, err.Error())
-		return 1
+	ctx := context.Background()
+	if fs.NArg() == 1 && !bf.filtered() {
+		p, err := newPrinter(of, taskColumns, taskDetailColumns)
+		if err != nil {
+			fmt.Fprintln(errOut, err.Error())
+			return 2
+		}
+		t, err := c.CancelTask(ctx, fs.Arg(0))
+		if err != nil {
+			return reportError(errOut, err)
+		}
+		return p.printOne(out, errOut, *t)
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+
+	p, err := newPrinter(of, batchColumns, "id,result,status,error")
 	if err != nil {
 		fmt.Fprintln(errOut, err.Error())
-		return 1
+		return 2
 	}
-	if resp.StatusCode >= 400 {
-		fmt.Fprintln(errOut, fmt.Errorf("request failed: %s: %s", resp.Status, string(body)).Error())
-		return 1
+	// only running tasks can be cancelled
+	if bf.status == "" && bf.filtered() {
+		bf.status = "running"
 	}
-	fmt.Fprintln(out, string(body))
-	return 0
-}
-
-func cancelWithClient(args []string, client *http.Client, baseURL string, out io.Writer, errOut io.Writer) int {
-	if len(args) != 1 {
-		usage(errOut)
-		return 2
+	ids, code := bf.targets(ctx, c, fs.Args(), "cancel", errOut)
+	if len(ids) == 0 {
+		return code
 	}
-	taskID := args[0]
-	req, _ := http.NewRequest("POST", baseURL+"/v1/tasks/"+taskID+"/cancel", nil)
-	resp, err := client.Do(req)
+	res, err := c.BatchCancel(ctx, molecular.BatchRequest{TaskIDs: ids})
 	if err != nil {
-		fmt.Fprintln(errOut, err.Error())
-		return 1
+		return reportError(errOut, err)
 	}
-	defer resp.Body.Close()
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode >= 400 {
-		fmt.Fprintln(errOut, fmt.Errorf("request failed: %s: %s", resp.Status, string(body)).Error())
-		return 1
-	}
-	fmt.Fprintln(out, string(body))
-	return 0
+	return printBatch(p, res, out, errOut)
 }
 
-func logsWithClient(args []string, client *http.Client, baseURL string, out io.Writer, errOut io.Writer) int {
+func logsWithClient(args []string, c *molecular.Client, out io.Writer, errOut io.Writer) int {
 	fs := flag.NewFlagSet("logs", flag.ContinueOnError)
 	fs.SetOutput(errOut)
 	var tail int
+	var follow bool
 	fs.IntVar(&tail, "tail", 0, "tail last N lines")
+	fs.BoolVar(&follow, "follow", false, "keep printing new lines until the task finishes")
+	fs.BoolVar(&follow, "f", false, "shorthand for --follow")
 	_ = fs.Parse(args)
 	if fs.NArg() != 1 {
 		usage(errOut)
 		return 2
 	}
 	taskID := fs.Arg(0)
-	u := baseURL + "/v1/tasks/" + taskID + "/logs"
-	if tail > 0 {
-		u = u + fmt.Sprintf("?tail=%d", tail)
-	}
-	resp, err := client.Get(u)
+	body, err := c.Logs(context.Background(), taskID, molecular.LogsOptions{Tail: tail, Follow: follow})
 	if err != nil {
+		return reportError(errOut, err)
+	}
+	defer body.Close()
+	if _, err := io.Copy(out, body); err != nil {
 		fmt.Fprintln(errOut, err.Error())
 		return 1
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	return 0
+}
+
+// cleanupWithClient removes one finished task's worktree and artifacts,
+// or several tasks' through the batch endpoint.
+func cleanupWithClient(args []string, c *molecular.Client, out io.Writer, errOut io.Writer) int {
+	fs := flag.NewFlagSet("cleanup", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	var of outputFlags
+	of.register(fs)
+	var bf batchFlags
+	bf.register(fs, "clean up")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	ctx := context.Background()
+	if fs.NArg() == 1 && !bf.filtered() {
+		p, err := newPrinter(of, cleanupColumns, "id,artifacts,worktree")
+		if err != nil {
+			fmt.Fprintln(errOut, err.Error())
+			return 2
+		}
+		res, err := c.CleanupTask(ctx, fs.Arg(0))
+		if err != nil {
+			return reportError(errOut, err)
+		}
+		if res.TaskID == "" {
+			res.TaskID = fs.Arg(0)
+		}
+		return p.printOne(out, errOut, *res)
+	}
+
+	p, err := newPrinter(of, batchColumns, "id,result,artifacts,worktree,error")
 	if err != nil {
 		fmt.Fprintln(errOut, err.Error())
-		return 1
+		return 2
 	}
-	if resp.StatusCode == http.StatusNotFound {
-		fmt.Fprintln(errOut, "logs not found")
-		return 1
+	ids, code := bf.targets(ctx, c, fs.Args(), "clean up", errOut)
+	if len(ids) == 0 {
+		return code
 	}
-	if resp.StatusCode >= 400 {
-		fmt.Fprintln(errOut, fmt.Errorf("request failed: %s: %s", resp.Status, string(body)).Error())
-		return 1
+	res, err := c.BatchCleanup(ctx, molecular.BatchRequest{TaskIDs: ids})
+	if err != nil {
+		return reportError(errOut, err)
 	}
-	fmt.Fprintln(out, string(body))
-	return 0
+	return printBatch(p, res, out, errOut)
 }
 
-func cleanupWithClient(args []string, client *http.Client, baseURL string, out io.Writer, errOut io.Writer) int {
-	if len(args) != 1 {
-		usage(errOut)
+// retryWithClient retries one task through its retry endpoint, or several
+// at once through the batch endpoint when given more IDs or selection
+// flags. --fresh and --budget apply to a single task only.
+func retryWithClient(args []string, c *molecular.Client, out io.Writer, errOut io.Writer) int {
+	fs := flag.NewFlagSet("retry", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	var of outputFlags
+	of.register(fs)
+	var bf batchFlags
+	bf.register(fs, "retry")
+	var fresh bool
+	fs.BoolVar(&fresh, "fresh", false, "check out a new worktree and branch instead of continuing in the original's")
+	budgets := budgetsFlag{}
+	fs.Var(budgets, "budget", "override an attempt budget, role=N (repeatable; roles carbon, helium, review)")
+	if err := fs.Parse(args); err != nil {
 		return 2
 	}
-	taskID := args[0]
-	// try placeholder endpoint
-	req, _ := http.NewRequest("POST", baseURL+"/v1/tasks/"+taskID+"/cleanup", nil)
-	resp, err := client.Do(req)
+	ctx := context.Background()
+	if fs.NArg() == 1 && !bf.filtered() {
+		p, err := newPrinter(of, taskColumns, taskDetailColumns)
+		if err != nil {
+			fmt.Fprintln(errOut, err.Error())
+			return 2
+		}
+		t, err := c.RetryTask(ctx, fs.Arg(0), molecular.RetryRequest{Fresh: fresh, Budgets: budgets})
+		if err != nil {
+			return reportError(errOut, err)
+		}
+		return p.printOne(out, errOut, *t)
+	}
+	if fresh || len(budgets) > 0 {
+		fmt.Fprintln(errOut, "retry: --fresh and --budget take a single task ID; batch retries always start fresh")
+		return 2
+	}
+
+	p, err := newPrinter(of, batchColumns, "id,result,task,error")
 	if err != nil {
 		fmt.Fprintln(errOut, err.Error())
-		return 1
-	}
-	defer resp.Body.Close()
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode == http.StatusNotFound {
-		fmt.Fprintln(errOut, "cleanup not implemented")
 		return 2
 	}
-	if resp.StatusCode >= 400 {
-		fmt.Fprintln(errOut, fmt.Errorf("request failed: %s: %s", resp.Status, string(body)).Error())
-		return 1
+	ids, code := bf.targets(ctx, c, fs.Args(), "retry", errOut)
+	if len(ids) == 0 {
+		return code
 	}
-	fmt.Fprintln(out, string(body))
-	return 0
+	res, err := c.BatchRetry(ctx, molecular.BatchRequest{TaskIDs: ids})
+	if err != nil {
+		return reportError(errOut, err)
+	}
+	return printBatch(p, res, out, errOut)
+}
+
+// budgetsFlag collects repeated --budget role=N flags.
+type budgetsFlag map[string]int
+
+func (b budgetsFlag) String() string {
+	keys := make([]string, 0, len(b))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%d", k, b[k]))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (b budgetsFlag) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	n, err := strconv.Atoi(v)
+	if !ok || k == "" || err != nil {
+		return fmt.Errorf("expected role=N, got %q", s)
+	}
+	b[k] = n
+	return nil
+}
+
+// cleanupColumns are the table columns for a cleanup result.
+var cleanupColumns = []column[molecular.CleanupResult]{
+	{"id", "TASK ID", func(r molecular.CleanupResult) string { return r.TaskID }},
+	{"artifacts", "ARTIFACTS REMOVED", func(r molecular.CleanupResult) string { return strconv.FormatBool(r.Artifacts) }},
+	{"worktree", "WORKTREE REMOVED", func(r molecular.CleanupResult) string { return strconv.FormatBool(r.Worktree) }},
 }
 
 // doctorWithIO implements the 'doctor' command. It checks for git/gh, the
@@ -12,7 +12,14 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/throw-if-null/molecular/internal/api"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// lastCreate and lastIdemKey record the most recent create request seen by
+// setupServer.
+var (
+	lastCreate  molecular.CreateTaskRequest
+	lastIdemKey string
 )
 
 func setupServer() *httptest.Server {
@@ -20,18 +27,22 @@ func setupServer() *httptest.Server {
 	mux.HandleFunc("/v1/tasks", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == "GET" {
 			limit := r.URL.Query().Get("limit")
-			var tasks []api.Task
+			var tasks []molecular.Task
 			for i := 1; i <= 3; i++ {
-				tasks = append(tasks, api.Task{TaskID: fmt.Sprintf("task-%d", i)})
+				tasks = append(tasks, molecular.Task{TaskID: fmt.Sprintf("task-%d", i)})
 			}
+			page := molecular.TaskList{Tasks: tasks}
 			if limit == "2" {
-				tasks = tasks[:2]
+				page = molecular.TaskList{Tasks: tasks[:2], NextCursor: "c2"}
 			}
-			_ = json.NewEncoder(w).Encode(tasks)
+			_ = json.NewEncoder(w).Encode(page)
 			return
 		}
 		if r.Method == "POST" {
 			// create
+			lastCreate = molecular.CreateTaskRequest{}
+			_ = json.NewDecoder(r.Body).Decode(&lastCreate)
+			lastIdemKey = r.Header.Get(molecular.IdempotencyKeyHeader)
 			w.WriteHeader(200)
 			w.Write([]byte(`{"ok":true}`))
 			return
@@ -41,7 +52,7 @@ func setupServer() *httptest.Server {
 
 	mux.HandleFunc("/v1/tasks/task-1/cancel", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == "POST" {
-			w.Write([]byte(`{"canceled":true}`))
+			w.Write([]byte(`{"task_id":"task-1","status":"cancelled","phase":"cancelled"}`))
 			return
 		}
 		w.WriteHeader(405)
@@ -68,7 +79,7 @@ func setupServer() *httptest.Server {
 	mux.HandleFunc("/v1/tasks/task-1", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == "GET" {
 			w.Header().Set("Content-Type", "application/json")
-			body := `{"task_id":"task-1","phase":"carbon","status":"running","carbon_budget":3,"helium_budget":3,"review_budget":2,"latest_attempt":{"id":42,"task_id":"task-1","role":"carbon","attempt_num":1,"status":"running","started_at":"","finished_at":"","artifacts_dir":"/tmp/x","error_summary":""}}`
+			body := `{"task_id":"task-1","phase":"carbon","status":"running","carbon_budget":3,"helium_budget":3,"review_budget":2,"worktree_path":"/tmp/wt","base_ref":"release/1.4","base_commit":"0123456789abcdef","branch_name":"feature/x","labels":{"epic":"billing","owner":"ana"},"usage":{"input_tokens":1200,"output_tokens":300,"cost_usd":0.0135},"latest_attempt":{"id":42,"task_id":"task-1","role":"carbon","attempt_num":1,"status":"running","started_at":"","finished_at":"","artifacts_dir":"/tmp/x","error_summary":""}}`
 			w.Write([]byte(body))
 			return
 		}
@@ -86,9 +97,7 @@ func TestListCancelLogsCleanup(t *testing.T) {
 
 	// list
 	buf := &bytes.Buffer{}
-	oldOut, wout := captureStdout(buf)
-	code := run([]string{"list"}, client, ts.URL, buf, bytes.NewBuffer(nil))
-	restoreStdout(oldOut, wout)
+	code := run([]string{"list", "--json"}, client, ts.URL, buf, bytes.NewBuffer(nil))
 	if code != 0 {
 		t.Fatalf("list exit code: %d", code)
 	}
@@ -103,9 +112,7 @@ func TestListCancelLogsCleanup(t *testing.T) {
 
 	// list --limit 2
 	buf.Reset()
-	oldOut, wout = captureStdout(buf)
-	code = run([]string{"list", "--limit", "2"}, client, ts.URL, buf, bytes.NewBuffer(nil))
-	restoreStdout(oldOut, wout)
+	code = run([]string{"list", "--limit", "2", "--json"}, client, ts.URL, buf, bytes.NewBuffer(nil))
 	if code != 0 {
 		t.Fatalf("list limit exit code: %d", code)
 	}
@@ -119,9 +126,7 @@ func TestListCancelLogsCleanup(t *testing.T) {
 
 	// cancel
 	buf.Reset()
-	oldOut, wout = captureStdout(buf)
-	code = run([]string{"cancel", "task-1"}, client, ts.URL, buf, bytes.NewBuffer(nil))
-	restoreStdout(oldOut, wout)
+	code = run([]string{"cancel", "-o", "json", "task-1"}, client, ts.URL, buf, bytes.NewBuffer(nil))
 	if code != 0 {
 		t.Fatalf("cancel exit code: %d", code)
 	}
@@ -130,7 +135,7 @@ func TestListCancelLogsCleanup(t *testing.T) {
 	if err := json.Unmarshal(b, &cres); err != nil {
 		t.Fatalf("unmarshal cancel: %v; body=%s", err, string(b))
 	}
-	if cres["canceled"] != true {
+	if cres["task_id"] != "task-1" || cres["status"] != "cancelled" {
 		t.Fatalf("unexpected cancel body: %v", cres)
 	}
 
@@ -151,9 +156,7 @@ func TestListCancelLogsCleanup(t *testing.T) {
 
 	// cleanup -> should call endpoint and print JSON
 	buf.Reset()
-	oldOut, wout = captureStdout(buf)
-	code = run([]string{"cleanup", "task-1"}, client, ts.URL, buf, bytes.NewBuffer(nil))
-	restoreStdout(oldOut, wout)
+	code = run([]string{"cleanup", "--output", "json", "task-1"}, client, ts.URL, buf, bytes.NewBuffer(nil))
 	if code != 0 {
 		t.Fatalf("cleanup exit code: %d", code)
 	}
@@ -167,39 +170,6 @@ func TestListCancelLogsCleanup(t *testing.T) {
 	}
 }
 
-// helpers to capture stdout/stderr
-func captureStdout(w *bytes.Buffer) (*os.File, *os.File) {
-	old := os.Stdout
-	r, wpipe, _ := os.Pipe()
-	os.Stdout = wpipe
-	go func() {
-		io.Copy(w, r)
-		r.Close()
-	}()
-	return old, wpipe
-}
-
-func restoreStdout(old *os.File, wpipe *os.File) {
-	_ = wpipe.Close()
-	os.Stdout = old
-}
-
-func captureStderr(w *bytes.Buffer) (*os.File, *os.File) {
-	old := os.Stderr
-	r, wpipe, _ := os.Pipe()
-	os.Stderr = wpipe
-	go func() {
-		io.Copy(w, r)
-		r.Close()
-	}()
-	return old, wpipe
-}
-
-func restoreStderr(old *os.File, wpipe *os.File) {
-	_ = wpipe.Close()
-	os.Stderr = old
-}
-
 func TestDoctorCommand(t *testing.T) {
 	// create a temp repo dir
 	d, err := os.MkdirTemp("", "molecular-doctor-test-")
@@ -270,22 +240,21 @@ func TestStatusOutput(t *testing.T) {
 	client := &http.Client{}
 	// human output
 	buf := &bytes.Buffer{}
-	oldOut, wout := captureStdout(buf)
 	code := run([]string{"status", "task-1"}, client, ts.URL, buf, bytes.NewBuffer(nil))
-	restoreStdout(oldOut, wout)
 	if code != 0 {
 		t.Fatalf("status exit code: %d", code)
 	}
 	out := buf.String()
-	if !strings.Contains(out, "task-1") || !strings.Contains(out, "latest attempt") {
+	if !strings.Contains(out, "task-1") || !strings.Contains(out, "42 carbon running") {
 		t.Fatalf("unexpected status output: %s", out)
 	}
+	if !strings.Contains(out, "feature/x  release/1.4@0123456789ab") {
+		t.Fatalf("expected branch/base in status output: %s", out)
+	}
 
 	// json mode
 	buf.Reset()
-	oldOut, wout = captureStdout(buf)
 	code = run([]string{"status", "--json", "task-1"}, client, ts.URL, buf, bytes.NewBuffer(nil))
-	restoreStdout(oldOut, wout)
 	if code != 0 {
 		t.Fatalf("status --json exit code: %d", code)
 	}
@@ -297,3 +266,52 @@ func TestStatusOutput(t *testing.T) {
 		t.Fatalf("unexpected json task_id: %v", j["task_id"])
 	}
 }
+
+func TestSubmitGitFlags(t *testing.T) {
+	ts := setupServer()
+	defer ts.Close()
+	client := &http.Client{}
+
+	out := &bytes.Buffer{}
+	code := run([]string{"submit", "--task-id", "task-9", "--prompt", "p", "--base", "release/1.4", "--branch", "feature/x"}, client, ts.URL, out, out)
+	if code != 0 {
+		t.Fatalf("submit exit code: %d, out=%s", code, out.String())
+	}
+	wd, _ := os.Getwd()
+	if lastCreate.BaseRef != "release/1.4" || lastCreate.BranchName != "feature/x" || lastCreate.RepoPath != wd {
+		t.Fatalf("unexpected create request: %+v", lastCreate)
+	}
+
+	code = run([]string{"submit", "--task-id", "task-9", "--prompt", "p"}, client, ts.URL, out, out)
+	if code != 0 {
+		t.Fatalf("submit exit code: %d, out=%s", code, out.String())
+	}
+	if lastCreate.RepoPath != "" {
+		t.Fatalf("expected no repo_path without git flags, got %q", lastCreate.RepoPath)
+	}
+}
+
+func TestSubmitIDOptions(t *testing.T) {
+	ts := setupServer()
+	defer ts.Close()
+	client := &http.Client{}
+	out := &bytes.Buffer{}
+
+	if code := run([]string{"submit", "--prompt", "p", "--idempotency-key", "k1"}, client, ts.URL, out, out); code != 0 {
+		t.Fatalf("submit exit code: %d, out=%s", code, out.String())
+	}
+	if lastCreate.TaskID != "" || lastIdemKey != "k1" {
+		t.Fatalf("expected generated id and idempotency key, got %+v key=%q", lastCreate, lastIdemKey)
+	}
+
+	if code := run([]string{"submit", "--task-id", "t1", "--prompt", "p", "--if-not-exists"}, client, ts.URL, out, out); code != 0 {
+		t.Fatalf("submit exit code: %d, out=%s", code, out.String())
+	}
+	if !lastCreate.IfNotExists || lastCreate.TaskID != "t1" {
+		t.Fatalf("expected if_not_exists for t1, got %+v", lastCreate)
+	}
+
+	if code := run([]string{"submit", "--prompt", "p", "--if-not-exists"}, client, ts.URL, out, out); code != 2 {
+		t.Fatalf("expected usage error without --task-id, got %d", code)
+	}
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+)
+
+// outputFlags are the --output, --columns and --no-headers flags shared by
+// every command that prints API objects.
+type outputFlags struct {
+	format    string
+	columns   string
+	noHeaders bool
+	json      bool
+}
+
+func (o *outputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.format, "output", "table", "table, json, yaml, jsonpath=<expr> or go-template=<tpl>")
+	fs.StringVar(&o.format, "o", "table", "shorthand for --output")
+	fs.StringVar(&o.columns, "columns", "", "comma-separated table columns")
+	fs.BoolVar(&o.noHeaders, "no-headers", false, "omit the table header row")
+	fs.BoolVar(&o.json, "json", false, "shorthand for --output json")
+}
+
+// column is one table column. name is what --columns selects.
+type column[T any] struct {
+	name   string
+	header string
+	value  func(T) string
+}
+
+// printer renders values in the format chosen by outputFlags. Table output
+// prints one row per item; the other formats print the JSON shape of the
+// value exactly as the API returns it.
+type printer[T any] struct {
+	kind     string // table, json, yaml, jsonpath or go-template
+	cols     []column[T]
+	headers  bool
+	jsonpath []pathSegment
+	tmpl     *template.Template
+}
+
+// newPrinter validates the output flags against the available columns.
+// defaults lists the columns shown when --columns is not set.
+func newPrinter[T any](o outputFlags, all []column[T], defaults string) (*printer[T], error) {
+	format := o.format
+	if o.json {
+		format = "json"
+	}
+	kind, arg, _ := strings.Cut(format, "=")
+	p := &printer[T]{kind: kind, headers: !o.noHeaders}
+	if o.columns != "" && kind != "table" {
+		return nil, errors.New("--columns only applies to table output")
+	}
+	switch kind {
+	case "table":
+		names := o.columns
+		if names == "" {
+			names = defaults
+		}
+		for _, name := range strings.Split(names, ",") {
+			c, ok := findColumn(all, strings.TrimSpace(name))
+			if !ok {
+				return nil, fmt.Errorf("unknown column %q (available: %s)", name, columnNames(all))
+			}
+			p.cols = append(p.cols, c)
+		}
+	case "json", "yaml":
+	case "jsonpath":
+		segs, err := parseJSONPath(arg)
+		if err != nil {
+			return nil, fmt.Errorf("jsonpath: %w", err)
+		}
+		p.jsonpath = segs
+	case "go-template":
+		t, err := template.New("output").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("go-template: %w", err)
+		}
+		p.tmpl = t
+	default:
+		return nil, fmt.Errorf("unknown output format %q (want table, json, yaml, jsonpath=<expr> or go-template=<tpl>)", format)
+	}
+	return p, nil
+}
+
+func findColumn[T any](all []column[T], name string) (column[T], bool) {
+	for _, c := range all {
+		if strings.EqualFold(c.name, name) {
+			return c, true
+		}
+	}
+	return column[T]{}, false
+}
+
+func columnNames[T any](all []column[T]) string {
+	names := make([]string, len(all))
+	for i, c := range all {
+		names[i] = c.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// printOne prints a single object.
+func (p *printer[T]) printOne(out, errOut io.Writer, v T) int {
+	return p.print(out, errOut, v, []T{v})
+}
+
+// printList prints a list of objects; non-table formats see a JSON array.
+func (p *printer[T]) printList(out, errOut io.Writer, vs []T) int {
+	if vs == nil {
+		vs = []T{}
+	}
+	return p.print(out, errOut, vs, vs)
+}
+
+func (p *printer[T]) print(out, errOut io.Writer, v any, rows []T) int {
+	if err := p.render(out, v, rows); err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 1
+	}
+	return 0
+}
+
+func (p *printer[T]) render(out io.Writer, v any, rows []T) error {
+	switch p.kind {
+	case "table":
+		tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		if p.headers {
+			hs := make([]string, len(p.cols))
+			for i, c := range p.cols {
+				hs[i] = c.header
+			}
+			fmt.Fprintln(tw, strings.Join(hs, "\t"))
+		}
+		for _, r := range rows {
+			cells := make([]string, len(p.cols))
+			for i, c := range p.cols {
+				if cells[i] = c.value(r); cells[i] == "" {
+					cells[i] = "-"
+				}
+			}
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+		return tw.Flush()
+	case "json":
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(b))
+		return err
+	}
+
+	data, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+	switch p.kind {
+	case "yaml":
+		_, err = io.WriteString(out, strings.Join(yamlLines(data), "\n")+"\n")
+	case "jsonpath":
+		_, err = io.WriteString(out, evalJSONPath(p.jsonpath, data))
+	case "go-template":
+		err = p.tmpl.Execute(out, data)
+	}
+	return err
+}
+
+// toGeneric round-trips v through JSON so yaml, jsonpath and templates see
+// the wire field names rather than Go field names.
+func toGeneric(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var out any
+	err = dec.Decode(&out)
+	return out, err
+}
+
+// yamlLines renders a decoded JSON value as block-style YAML, one line per
+// element, with map keys sorted.
+func yamlLines(v any) []string {
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			return []string{"{}"}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var lines []string
+		for _, k := range keys {
+			sub := yamlLines(v[k])
+			if !yamlNested(v[k]) {
+				lines = append(lines, yamlString(k)+": "+sub[0])
+				continue
+			}
+			lines = append(lines, yamlString(k)+":")
+			for _, l := range sub {
+				lines = append(lines, "  "+l)
+			}
+		}
+		return lines
+	case []any:
+		if len(v) == 0 {
+			return []string{"[]"}
+		}
+		var lines []string
+		for _, item := range v {
+			sub := yamlLines(item)
+			lines = append(lines, "- "+sub[0])
+			for _, l := range sub[1:] {
+				lines = append(lines, "  "+l)
+			}
+		}
+		return lines
+	case string:
+		return []string{yamlString(v)}
+	case nil:
+		return []string{"null"}
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+// yamlNested reports whether v needs its own block below a map key.
+func yamlNested(v any) bool {
+	switch v := v.(type) {
+	case map[string]any:
+		return len(v) > 0
+	case []any:
+		return len(v) > 0
+	}
+	return false
+}
+
+// yamlIndicators are the YAML indicator characters; a plain scalar cannot
+// start with one. "." and "+" are added so .5 and +1 stay strings.
+const yamlIndicators = "-?:,[]{}#&*!|>'\"%@`" + ".+"
+
+// yamlString quotes s unless it is unambiguous as a plain YAML scalar.
+func yamlString(s string) string {
+	if s == "" || strings.TrimSpace(s) != s || strings.ContainsAny(s[:1], yamlIndicators) {
+		return strconv.Quote(s)
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	for _, r := range s {
+		plain := r == ' ' || r == '_' || r == '-' || r == '.' || r == '/' || r == '@' || r == '+' ||
+			('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')
+		if !plain {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+// pathSegment is one piece of a jsonpath expression: either literal text
+// copied to the output or a path evaluated against the value.
+type pathSegment struct {
+	literal bool
+	text    string
+	steps   []pathStep
+}
+
+// pathStep selects a map key, a list index, or every element (wildcard).
+type pathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJSONPath parses the subset of kubectl-style JSONPath the CLI
+// supports: text with {…} expressions built from .field, [n] and [*].
+// An expression without braces is treated as a single path.
+func parseJSONPath(expr string) ([]pathSegment, error) {
+	if !strings.Contains(expr, "{") {
+		expr = "{" + expr + "}"
+	}
+	var segs []pathSegment
+	for expr != "" {
+		open := strings.IndexByte(expr, '{')
+		if open < 0 {
+			segs = append(segs, pathSegment{literal: true, text: expr})
+			break
+		}
+		if open > 0 {
+			segs = append(segs, pathSegment{literal: true, text: expr[:open]})
+		}
+		end := strings.IndexByte(expr[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed { in %q", expr)
+		}
+		steps, err := parsePathSteps(expr[open+1 : open+end])
+		if err != nil {
+			return nil, err
+		}
+		segs = append(segs, pathSegment{steps: steps})
+		expr = expr[open+end+1:]
+	}
+	return segs, nil
+}
+
+func parsePathSteps(p string) ([]pathStep, error) {
+	p = strings.TrimPrefix(strings.TrimSpace(p), "$")
+	var steps []pathStep
+	for p != "" {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			n := strings.IndexAny(p, ".[")
+			if n < 0 {
+				n = len(p)
+			}
+			switch key := p[:n]; key {
+			case "":
+				// "." alone is the root; ".[0]" indexes it
+			case "*":
+				steps = append(steps, pathStep{wildcard: true})
+			default:
+				steps = append(steps, pathStep{key: key})
+			}
+			p = p[n:]
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in %q", p)
+			}
+			in := p[1:end]
+			p = p[end+1:]
+			if in == "*" {
+				steps = append(steps, pathStep{wildcard: true})
+				continue
+			}
+			i, err := strconv.Atoi(in)
+			if err != nil {
+				return nil, fmt.Errorf("bad index [%s]", in)
+			}
+			steps = append(steps, pathStep{index: i, isIndex: true})
+		default:
+			return nil, fmt.Errorf("unexpected %q, want .field or [n]", p)
+		}
+	}
+	return steps, nil
+}
+
+// evalJSONPath renders segs against v. Multiple results from a wildcard
+// are joined with spaces; missing keys and indexes produce nothing.
+func evalJSONPath(segs []pathSegment, v any) string {
+	var b strings.Builder
+	for _, s := range segs {
+		if s.literal {
+			b.WriteString(s.text)
+			continue
+		}
+		results := []any{v}
+		for _, st := range s.steps {
+			results = applyStep(st, results)
+		}
+		for i, r := range results {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(formatPathResult(r))
+		}
+	}
+	return b.String()
+}
+
+func applyStep(st pathStep, in []any) []any {
+	var out []any
+	for _, v := range in {
+		switch {
+		case st.wildcard:
+			switch v := v.(type) {
+			case []any:
+				out = append(out, v...)
+			case map[string]any:
+				keys := make([]string, 0, len(v))
+				for k := range v {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+				for _, k := range keys {
+					out = append(out, v[k])
+				}
+			}
+		case st.isIndex:
+			if l, ok := v.([]any); ok {
+				i := st.index
+				if i < 0 {
+					i += len(l)
+				}
+				if i >= 0 && i < len(l) {
+					out = append(out, l[i])
+				}
+			}
+		default:
+			if m, ok := v.(map[string]any); ok {
+				if x, ok := m[st.key]; ok {
+					out = append(out, x)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func formatPathResult(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case map[string]any, []any:
+		b, _ := json.Marshal(v)
+		return string(b)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden from the current output")
+
+func TestOutputFormatsGolden(t *testing.T) {
+	ts := setupServer()
+	defer ts.Close()
+
+	cases := []struct {
+		golden string
+		args   []string
+	}{
+		{"status_table", []string{"status", "task-1"}},
+		{"status_columns", []string{"status", "--columns", "id,worktree,attempt", "task-1"}},
+		{"status_yaml", []string{"status", "-o", "yaml", "task-1"}},
+		{"status_jsonpath", []string{"status", "-o", "jsonpath={.task_id} {.latest_attempt.role}", "task-1"}},
+		{"status_json", []string{"status", "--json", "task-1"}},
+		{"list_no_headers", []string{"list", "--no-headers", "--columns", "id,status"}},
+		{"list_jsonpath", []string{"list", "--output", "jsonpath={[*].task_id}"}},
+		{"list_template", []string{"list", "-o", `go-template={{range .}}{{.task_id}}{{"\n"}}{{end}}`}},
+		{"cancel_table", []string{"cancel", "task-1"}},
+		{"cleanup_yaml", []string{"cleanup", "-o", "yaml", "task-1"}},
+	}
+	for _, c := range cases {
+		t.Run(c.golden, func(t *testing.T) {
+			out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+			if code := run(c.args, ts.Client(), ts.URL, out, errOut); code != 0 {
+				t.Fatalf("exit %d: %s", code, errOut.String())
+			}
+			path := filepath.Join("testdata", c.golden+".golden")
+			if *update {
+				if err := os.WriteFile(path, out.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if out.String() != string(want) {
+				t.Fatalf("output differs from %s:\ngot:\n%s\nwant:\n%s", path, out.String(), want)
+			}
+		})
+	}
+}
+
+func TestOutputFlagErrors(t *testing.T) {
+	ts := setupServer()
+	defer ts.Close()
+
+	for _, args := range [][]string{
+		{"status", "-o", "xml", "task-1"},
+		{"status", "--columns", "id,nope", "task-1"},
+		{"list", "-o", "json", "--columns", "id"},
+		{"list", "-o", "jsonpath={.tasks[x]}"},
+		{"list", "-o", "go-template={{.task_id"},
+	} {
+		errOut := &bytes.Buffer{}
+		if code := run(args, ts.Client(), ts.URL, &bytes.Buffer{}, errOut); code != 2 || errOut.Len() == 0 {
+			t.Fatalf("%v: expected usage error, got %d %q", args, code, errOut.String())
+		}
+	}
+}
+
+func TestYAMLStrings(t *testing.T) {
+	cases := map[string]string{
+		"running":              "running",
+		"feature/x":            "feature/x",
+		"":                     `""`,
+		"true":                 `"true"`,
+		"3":                    `"3"`,
+		"-x":                   `"-x"`,
+		"2026-01-02T10:00:00Z": `"2026-01-02T10:00:00Z"`,
+		"a: b":                 `"a: b"`,
+		"two\nlines":           `"two\nlines"`,
+		"@foo":                 `"@foo"`,
+		"a@b.c":                "a@b.c",
+		"`cmd`":                "\"`cmd`\"",
+		"!tag":                 `"!tag"`,
+		"&anchor":              `"&anchor"`,
+		"*alias":               `"*alias"`,
+		"%dir":                 `"%dir"`,
+		"|":                    `"|"`,
+		">x":                   `">x"`,
+		"#x":                   `"#x"`,
+		"'q'":                  `"'q'"`,
+		"+1":                   `"+1"`,
+	}
+	for in, want := range cases {
+		if got := yamlString(in); got != want {
+			t.Errorf("yamlString(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
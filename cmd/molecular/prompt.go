@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// stdin is a variable to allow tests to supply prompt input.
+var stdin io.Reader = os.Stdin
+
+// templatesDir is where 'submit --template' looks up prompt templates.
+var templatesDir = filepath.Join(".molecular", "templates")
+
+// varsFlag collects repeated --var k=v flags.
+type varsFlag map[string]string
+
+func (v varsFlag) String() string {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+v[k])
+	}
+	return strings.Join(parts, ",")
+}
+
+func (v varsFlag) Set(s string) error {
+	k, val, ok := strings.Cut(s, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("expected key=value, got %q", s)
+	}
+	v[k] = val
+	return nil
+}
+
+// errPromptSource reports a usage error in choosing the prompt source.
+var errPromptSource = errors.New("exactly one of --prompt, --prompt-file or --template is required")
+
+// resolvePrompt picks the prompt from exactly one of an inline value ("-"
+// meaning stdin), a file, or a template. Template metadata is returned
+// alongside the rendered prompt when a template was used.
+func resolvePrompt(prompt, promptFile, tmpl string, vars map[string]string) (string, *molecular.PromptTemplate, error) {
+	n := 0
+	for _, s := range []string{prompt, promptFile, tmpl} {
+		if s != "" {
+			n++
+		}
+	}
+	if n != 1 {
+		return "", nil, errPromptSource
+	}
+	if len(vars) > 0 && tmpl == "" {
+		return "", nil, fmt.Errorf("%w: --var requires --template", errPromptSource)
+	}
+
+	switch {
+	case prompt == "-":
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", nil, err
+		}
+		return nonEmpty(string(b), "stdin")
+	case prompt != "":
+		return prompt, nil, nil
+	case promptFile != "":
+		b, err := os.ReadFile(promptFile)
+		if err != nil {
+			return "", nil, err
+		}
+		return nonEmpty(string(b), promptFile)
+	default:
+		return renderTemplate(tmpl, vars)
+	}
+}
+
+func nonEmpty(p, from string) (string, *molecular.PromptTemplate, error) {
+	if strings.TrimSpace(p) == "" {
+		return "", nil, fmt.Errorf("empty prompt from %s", from)
+	}
+	return p, nil, nil
+}
+
+// renderTemplate renders .molecular/templates/<name>.tmpl with vars
+// available as {{.Vars.key}} (or {{.key}}). Missing keys are errors so a
+// typo cannot silently produce an incomplete prompt.
+func renderTemplate(name string, vars map[string]string) (string, *molecular.PromptTemplate, error) {
+	if strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", nil, fmt.Errorf("invalid template name %q", name)
+	}
+	file := name
+	if filepath.Ext(file) == "" {
+		file += ".tmpl"
+	}
+	src, err := os.ReadFile(filepath.Join(templatesDir, file))
+	if err != nil {
+		return "", nil, err
+	}
+	t, err := template.New(name).Option("missingkey=error").Parse(string(src))
+	if err != nil {
+		return "", nil, err
+	}
+
+	data := map[string]any{"Vars": vars}
+	for k, v := range vars {
+		if _, reserved := data[k]; !reserved {
+			data[k] = v
+		}
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", nil, err
+	}
+
+	sum := sha256.Sum256(src)
+	meta := &molecular.PromptTemplate{
+		Name:   name,
+		SHA256: hex.EncodeToString(sum[:]),
+		Source: string(src),
+	}
+	if len(vars) > 0 {
+		meta.Vars = vars
+	}
+	return buf.String(), meta, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSubmitPromptSources(t *testing.T) {
+	ts := setupServer()
+	defer ts.Close()
+	client := &http.Client{}
+
+	d := t.TempDir()
+	oldWd, _ := os.Getwd()
+	_ = os.Chdir(d)
+	defer os.Chdir(oldWd)
+
+	if err := os.MkdirAll(filepath.Join(".molecular", "templates"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tmplSrc := "Fix {{.Vars.bug}} in {{.component}}.\n"
+	if err := os.WriteFile(filepath.Join(".molecular", "templates", "bugfix.tmpl"), []byte(tmplSrc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("spec.md", []byte("line one\n\nline two\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldStdin := stdin
+	stdin = strings.NewReader("from stdin\n")
+	defer func() { stdin = oldStdin }()
+
+	out := &bytes.Buffer{}
+	submit := func(args ...string) int {
+		out.Reset()
+		return run(append([]string{"submit", "--task-id", "t"}, args...), client, ts.URL, out, out)
+	}
+
+	if code := submit("--prompt", "-"); code != 0 || lastCreate.Prompt != "from stdin\n" {
+		t.Fatalf("stdin: code=%d prompt=%q out=%s", code, lastCreate.Prompt, out.String())
+	}
+	if code := submit("--prompt-file", "spec.md"); code != 0 || lastCreate.Prompt != "line one\n\nline two\n" {
+		t.Fatalf("file: code=%d prompt=%q out=%s", code, lastCreate.Prompt, out.String())
+	}
+	if lastCreate.PromptTemplate != nil {
+		t.Fatalf("expected no template metadata for file prompt")
+	}
+
+	if code := submit("--template", "bugfix", "--var", "bug=NPE", "--var", "component=parser"); code != 0 {
+		t.Fatalf("template: code=%d out=%s", code, out.String())
+	}
+	if lastCreate.Prompt != "Fix NPE in parser.\n" {
+		t.Fatalf("unexpected rendered prompt: %q", lastCreate.Prompt)
+	}
+	meta := lastCreate.PromptTemplate
+	if meta == nil || meta.Name != "bugfix" || meta.Source != tmplSrc || meta.Vars["bug"] != "NPE" || len(meta.SHA256) != 64 {
+		t.Fatalf("unexpected template metadata: %+v", meta)
+	}
+
+	// missing variable is an error, not a silently incomplete prompt
+	if code := submit("--template", "bugfix", "--var", "bug=NPE"); code != 1 {
+		t.Fatalf("expected exit 1 for missing var, got %d", code)
+	}
+	// conflicting sources are a usage error
+	if code := submit("--prompt", "x", "--prompt-file", "spec.md"); code != 2 {
+		t.Fatalf("expected exit 2 for conflicting sources, got %d", code)
+	}
+	if code := submit(); code != 2 {
+		t.Fatalf("expected exit 2 without a prompt, got %d", code)
+	}
+	if code := submit("--template", "../escape"); code != 1 {
+		t.Fatalf("expected exit 1 for invalid template name, got %d", code)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/throw-if-null/molecular/internal/api"
+)
+
+// Environment variables consulted when the matching global flag is unset.
+const (
+	envServer = "MOLECULAR_SERVER"
+	envCACert = "MOLECULAR_CACERT"
+	envCert   = "MOLECULAR_CERT"
+	envKey    = "MOLECULAR_KEY"
+)
+
+// globalOptions are flags accepted before the subcommand.
+type globalOptions struct {
+	server string
+	token  string
+	cacert string
+	cert   string
+	key    string
+}
+
+// tls reports whether the connection to Silicon should use HTTPS.
+func (o globalOptions) tls() bool {
+	return strings.HasPrefix(o.server, "https://") || o.cacert != "" || o.cert != ""
+}
+
+// parseGlobal parses the flags that precede the subcommand and returns the
+// remaining arguments.
+func parseGlobal(args []string, errOut io.Writer) (globalOptions, []string, error) {
+	var opts globalOptions
+	fs := flag.NewFlagSet("molecular", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	fs.StringVar(&opts.server, "server", "", "Silicon address: host:port, http(s)://host:port or unix:///path (env "+envServer+")")
+	fs.StringVar(&opts.cacert, "cacert", "", "PEM CA bundle to verify Silicon's certificate (env "+envCACert+")")
+	fs.StringVar(&opts.cert, "cert", "", "PEM client certificate for mTLS (env "+envCert+")")
+	fs.StringVar(&opts.key, "key", "", "PEM private key for --cert (env "+envKey+")")
+	if err := fs.Parse(args); err != nil {
+		return opts, nil, err
+	}
+	for _, f := range []struct {
+		v   *string
+		env string
+	}{{&opts.server, envServer}, {&opts.cacert, envCACert}, {&opts.cert, envCert}, {&opts.key, envKey}} {
+		if *f.v == "" {
+			*f.v = os.Getenv(f.env)
+		}
+	}
+	if opts.server == "" {
+		opts.server = api.DefaultAddress()
+	}
+	tok, err := loadToken()
+	if err != nil {
+		return opts, nil, err
+	}
+	opts.token = tok
+	return opts, fs.Args(), nil
+}
+
+// newClient builds an HTTP client and base URL for a Silicon address. Unix
+// socket addresses get a transport that always dials the socket; the host
+// in the returned base URL is then only a placeholder.
+func newClient(opts globalOptions) (*http.Client, string, error) {
+	network, address, err := api.ParseAddress(opts.server)
+	if err != nil {
+		return nil, "", err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	scheme := "http://"
+	if opts.tls() {
+		cfg, err := clientTLSConfig(opts)
+		if err != nil {
+			return nil, "", err
+		}
+		transport.TLSClientConfig = cfg
+		scheme = "https://"
+	}
+	baseURL := scheme + address
+	if network == "unix" {
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", address)
+		}
+		baseURL = scheme + "unix"
+	}
+	var rt http.RoundTripper = transport
+	if opts.token != "" {
+		rt = &bearerTransport{token: opts.token, base: transport}
+	}
+	// the timeout bounds ordinary calls; the client lifts it for logs -f,
+	// events and downloads
+	return &http.Client{Timeout: 30 * time.Second, Transport: rt}, baseURL, nil
+}
+
+// clientTLSConfig loads the CA bundle and client key pair named in opts.
+func clientTLSConfig(opts globalOptions) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts.cacert != "" {
+		pem, err := os.ReadFile(opts.cacert)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.cacert)
+		}
+		cfg.RootCAs = pool
+	}
+	if (opts.cert == "") != (opts.key == "") {
+		return nil, errors.New("--cert and --key must be set together")
+	}
+	if opts.cert != "" {
+		pair, err := tls.LoadX509KeyPair(opts.cert, opts.key)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+	}
+	return cfg, nil
+}
+
+// bearerTransport adds an Authorization header to every request.
+type bearerTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *bearerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(r)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/throw-if-null/molecular/internal/api"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+func TestParseGlobal(t *testing.T) {
+	t.Setenv(envServer, "")
+	opts, rest, err := parseGlobal([]string{"list", "--limit", "2"}, &bytes.Buffer{})
+	if err != nil || opts.server != api.DefaultAddress() || len(rest) != 3 {
+		t.Fatalf("defaults: %+v %v %v", opts, rest, err)
+	}
+
+	t.Setenv(envServer, "unix:///tmp/env.sock")
+	opts, _, _ = parseGlobal([]string{"list"}, &bytes.Buffer{})
+	if opts.server != "unix:///tmp/env.sock" {
+		t.Fatalf("expected env server, got %q", opts.server)
+	}
+
+	opts, rest, _ = parseGlobal([]string{"--server", "127.0.0.1:9000", "status", "t1"}, &bytes.Buffer{})
+	if opts.server != "127.0.0.1:9000" || len(rest) != 2 || rest[0] != "status" {
+		t.Fatalf("flag: %+v %v", opts, rest)
+	}
+}
+
+func TestNewClient_UnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "silicon.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/tasks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(molecular.TaskList{Tasks: []molecular.Task{{TaskID: "over-socket"}}})
+	})
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	client, baseURL, err := newClient(globalOptions{server: api.UnixScheme + sock})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	out := &bytes.Buffer{}
+	if code := run([]string{"list"}, client, baseURL, out, out); code != 0 {
+		t.Fatalf("list over unix socket: exit %d, out=%s", code, out.String())
+	}
+	if !bytes.Contains(out.Bytes(), []byte("over-socket")) {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+
+	if _, _, err := newClient(globalOptions{server: "no-port"}); err == nil {
+		t.Fatalf("expected error for invalid address")
+	}
+}
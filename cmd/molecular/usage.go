@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// usageRow is one group in the usage report.
+type usageRow struct {
+	Group        string  `json:"group"`
+	Tasks        int     `json:"tasks"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// usageWithClient implements 'usage', summarizing the tokens and cost of
+// the tasks created in a time range, grouped by model, task, status, day
+// or label.
+func usageWithClient(args []string, c *molecular.Client, out io.Writer, errOut io.Writer) int {
+	fs := flag.NewFlagSet("usage", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	var since, until, by, selector string
+	fs.StringVar(&since, "since", "", "only tasks created at or after this RFC 3339 time or duration ago (e.g. 7d)")
+	fs.StringVar(&until, "until", "", "only tasks created before this RFC 3339 time or duration ago")
+	fs.StringVar(&by, "by", "model", "group by model, task, status, day, label (every k=v) or label:<key>")
+	fs.StringVar(&selector, "selector", "", "only tasks whose labels match, e.g. epic=billing")
+	fs.StringVar(&selector, "l", "", "shorthand for --selector")
+	var of outputFlags
+	of.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		usage(errOut)
+		return 2
+	}
+	group, header, err := usageGrouping(by)
+	if err != nil {
+		fmt.Fprintf(errOut, "--by: %v\n", err)
+		return 2
+	}
+	opts := molecular.ListTasksOptions{Selector: selector}
+	now := time.Now()
+	if opts.Since, err = parseTimeFlag(since, now); err != nil {
+		fmt.Fprintf(errOut, "--since: %v\n", err)
+		return 2
+	}
+	if opts.Until, err = parseTimeFlag(until, now); err != nil {
+		fmt.Fprintf(errOut, "--until: %v\n", err)
+		return 2
+	}
+	p, err := newPrinter(of, usageColumns(header), "group,tasks,input,output,cost")
+	if err != nil {
+		fmt.Fprintln(errOut, err.Error())
+		return 2
+	}
+
+	tasks, err := c.ListAllTasks(context.Background(), opts)
+	if err != nil {
+		return reportError(errOut, err)
+	}
+	return p.printList(out, errOut, usageRows(tasks, group))
+}
+
+// usageGrouping returns the function splitting a task's usage into groups
+// for --by, and the table header for the group column. Tasks that
+// reported no usage are left out.
+func usageGrouping(by string) (func(molecular.Task) map[string]molecular.Usage, string, error) {
+	total := func(key func(molecular.Task) []string) func(molecular.Task) map[string]molecular.Usage {
+		return func(t molecular.Task) map[string]molecular.Usage {
+			if t.Usage == nil {
+				return nil
+			}
+			groups := map[string]molecular.Usage{}
+			for _, k := range key(t) {
+				groups[k] = *t.Usage
+			}
+			return groups
+		}
+	}
+	switch by {
+	case "model":
+		return func(t molecular.Task) map[string]molecular.Usage {
+			groups := map[string]molecular.Usage{}
+			for _, u := range t.UsageByModel {
+				groups[u.Model] = u
+			}
+			return groups
+		}, "MODEL", nil
+	case "task":
+		return total(func(t molecular.Task) []string { return []string{t.TaskID} }), "TASK ID", nil
+	case "status":
+		return total(func(t molecular.Task) []string { return []string{string(t.Status)} }), "STATUS", nil
+	case "day":
+		return total(func(t molecular.Task) []string {
+			day, _, _ := strings.Cut(t.CreatedAt, "T")
+			return []string{day}
+		}), "DAY", nil
+	case "label":
+		// a task counts once for each of its labels
+		return total(func(t molecular.Task) []string {
+			if len(t.Labels) == 0 {
+				return []string{""}
+			}
+			keys := make([]string, 0, len(t.Labels))
+			for k, v := range t.Labels {
+				keys = append(keys, k+"="+v)
+			}
+			return keys
+		}), "LABEL", nil
+	}
+	if key, ok := strings.CutPrefix(by, "label:"); ok && key != "" {
+		return total(func(t molecular.Task) []string { return []string{t.Labels[key]} }), strings.ToUpper(key), nil
+	}
+	return nil, "", fmt.Errorf("want model, task, status, day, label or label:<key>, got %q", by)
+}
+
+// usageRows sums the grouped usage of tasks, most expensive group first.
+func usageRows(tasks []molecular.Task, group func(molecular.Task) map[string]molecular.Usage) []usageRow {
+	byGroup := map[string]*usageRow{}
+	for _, t := range tasks {
+		for g, u := range group(t) {
+			r := byGroup[g]
+			if r == nil {
+				r = &usageRow{Group: g}
+				byGroup[g] = r
+			}
+			r.Tasks++
+			r.InputTokens += u.InputTokens
+			r.OutputTokens += u.OutputTokens
+			r.CostUSD += u.CostUSD
+		}
+	}
+	rows := make([]usageRow, 0, len(byGroup))
+	for _, r := range byGroup {
+		rows = append(rows, *r)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].CostUSD != rows[j].CostUSD {
+			return rows[i].CostUSD > rows[j].CostUSD
+		}
+		return rows[i].Group < rows[j].Group
+	})
+	return rows
+}
+
+// usageColumns are the table columns of the usage report; header names
+// the group column.
+func usageColumns(header string) []column[usageRow] {
+	return []column[usageRow]{
+		{"group", header, func(r usageRow) string { return r.Group }},
+		{"tasks", "TASKS", func(r usageRow) string { return fmt.Sprint(r.Tasks) }},
+		{"input", "INPUT TOKENS", func(r usageRow) string { return fmt.Sprint(r.InputTokens) }},
+		{"output", "OUTPUT TOKENS", func(r usageRow) string { return fmt.Sprint(r.OutputTokens) }},
+		{"cost", "COST", func(r usageRow) string { return formatCost(r.CostUSD) }},
+	}
+}
+
+// formatTokens renders input and output token counts.
+func formatTokens(u molecular.Usage) string {
+	return fmt.Sprintf("%d in / %d out", u.InputTokens, u.OutputTokens)
+}
+
+// formatCost renders a cost in US dollars.
+func formatCost(usd float64) string {
+	return fmt.Sprintf("$%.4f", usd)
+}
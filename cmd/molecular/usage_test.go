@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+func TestUsageCommand(t *testing.T) {
+	var query string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/tasks", func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		_ = json.NewEncoder(w).Encode(molecular.TaskList{Tasks: []molecular.Task{
+			{TaskID: "t1", Status: "completed", CreatedAt: "2026-10-01T10:00:00Z", Labels: map[string]string{"epic": "billing"},
+				Usage: &molecular.Usage{InputTokens: 1000, OutputTokens: 200, CostUSD: 0.5},
+				UsageByModel: []molecular.Usage{
+					{Model: "large", InputTokens: 800, OutputTokens: 150, CostUSD: 0.45},
+					{Model: "small", InputTokens: 200, OutputTokens: 50, CostUSD: 0.05},
+				}},
+			{TaskID: "t2", Status: "failed", CreatedAt: "2026-10-02T10:00:00Z", Labels: map[string]string{"epic": "search"},
+				Usage:        &molecular.Usage{InputTokens: 100, OutputTokens: 10, CostUSD: 0.01},
+				UsageByModel: []molecular.Usage{{Model: "small", InputTokens: 100, OutputTokens: 10, CostUSD: 0.01}}},
+			{TaskID: "t3", Status: "running", CreatedAt: "2026-10-02T11:00:00Z"},
+		}})
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	cli := func(args ...string) (int, string) {
+		t.Helper()
+		out := &bytes.Buffer{}
+		return run(args, ts.Client(), ts.URL, out, io.Discard), out.String()
+	}
+
+	code, out := cli("usage", "--since", "7d", "-l", "team=web", "--no-headers")
+	if code != 0 || out != "large  1  800  150  $0.4500\nsmall  2  300  60   $0.0600\n" {
+		t.Fatalf("by model: exit %d\n%s", code, out)
+	}
+	if q, _ := url.ParseQuery(query); q.Get("since") == "" || q.Get("selector") != "team=web" {
+		t.Fatalf("unexpected query %q", query)
+	}
+	if code, out := cli("usage", "--by", "label:epic", "--columns", "group,cost"); code != 0 || out != "EPIC     COST\nbilling  $0.5000\nsearch   $0.0100\n" {
+		t.Fatalf("by label key: exit %d\n%s", code, out)
+	}
+	if code, out := cli("usage", "--by", "day", "--no-headers", "--columns", "group,tasks"); code != 0 || out != "2026-10-01  1\n2026-10-02  1\n" {
+		t.Fatalf("by day: exit %d\n%s", code, out)
+	}
+	for _, args := range [][]string{{"usage", "--by", "repo"}, {"usage", "--by", "label:"}, {"usage", "t1"}, {"usage", "--since", "soon"}} {
+		if code, _ := cli(args...); code != 2 {
+			t.Fatalf("%v: expected usage error, got %d", args, code)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+func (s *server) handleArtifactsList(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	_, ok := s.tasks[id]
+	s.mu.Unlock()
+	if !ok {
+		taskNotFound(w, r, id)
+		return
+	}
+	list, err := s.store.List(id)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, molecular.CodeInternal, "listing artifacts", nil)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(list)
+}
+
+func (s *server) handleArtifactGet(w http.ResponseWriter, r *http.Request, id, rel string) {
+	s.mu.Lock()
+	_, ok := s.tasks[id]
+	s.mu.Unlock()
+	if !ok {
+		taskNotFound(w, r, id)
+		return
+	}
+	f, info, err := s.store.Open(id, rel)
+	if err != nil {
+		switch {
+		case errors.Is(err, artifacts.ErrInvalidPath):
+			writeError(w, r, http.StatusBadRequest, molecular.CodeInvalidRequest, "invalid artifact path", map[string]any{"path": rel})
+		case errors.Is(err, fs.ErrNotExist):
+			writeError(w, r, http.StatusNotFound, molecular.CodeNotFound, "artifact not found", map[string]any{"path": rel})
+		default:
+			writeError(w, r, http.StatusInternalServerError, molecular.CodeInternal, "reading artifact", nil)
+		}
+		return
+	}
+	defer f.Close()
+	streamResponse(w)
+	// ServeContent handles Range/If-Modified-Since and sniffs the type
+	// from the extension, falling back to the content itself.
+	http.ServeContent(w, r, path.Base(rel), info.ModTime(), f)
+}
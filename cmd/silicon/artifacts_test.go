@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/internal/prompt"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// waitTerminal polls the task until it leaves the running state.
+func waitTerminal(t *testing.T, base, id string) molecular.Task {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		if time.Now().After(deadline) {
+			t.Fatalf("task %s did not reach terminal state in time", id)
+		}
+		resp, err := http.Get(base + "/v1/tasks/" + id)
+		if err != nil {
+			t.Fatalf("get task: %v", err)
+		}
+		var got molecular.Task
+		_ = json.NewDecoder(resp.Body).Decode(&got)
+		resp.Body.Close()
+		if got.Status != "running" {
+			return got
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestArtifacts_ListAndDownload(t *testing.T) {
+	store := artifacts.New(t.TempDir())
+	srv := httptest.NewServer(newServer(store))
+	defer srv.Close()
+
+	b, _ := json.Marshal(molecular.CreateTaskRequest{TaskID: "task-1", Prompt: "hello"})
+	resp, err := http.Post(srv.URL+"/v1/tasks", "application/json", bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("post create: %v", err)
+	}
+	var created molecular.Task
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if created.ArtifactsRoot != store.TaskDir("task-1") {
+		t.Fatalf("unexpected artifacts_root: %q", created.ArtifactsRoot)
+	}
+
+	got := waitTerminal(t, srv.URL, "task-1")
+	if got.LatestAttempt == nil || got.LatestAttempt.ArtifactsDir == "" {
+		t.Fatalf("expected latest attempt with artifacts dir, got %+v", got.LatestAttempt)
+	}
+
+	resp, err = http.Get(srv.URL + "/v1/tasks/task-1/artifacts")
+	if err != nil {
+		t.Fatalf("list artifacts: %v", err)
+	}
+	var list []molecular.Artifact
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatalf("decode artifacts: %v", err)
+	}
+	resp.Body.Close()
+
+	prompt := "attempts/001-carbon/prompts/carbon.md"
+	found := false
+	for _, a := range list {
+		if a.Path == prompt {
+			found = true
+			if a.Size == 0 || len(a.SHA256) != 64 {
+				t.Fatalf("unexpected prompt artifact: %+v", a)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("prompt artifact missing from %+v", list)
+	}
+	if !slices.ContainsFunc(list, func(a molecular.Artifact) bool { return a.Path == "attempts/001-carbon/prompts/carbon.json" }) {
+		t.Fatalf("prompt render record missing from %+v", list)
+	}
+
+	resp, err = http.Get(srv.URL + "/v1/tasks/task-1/artifacts/" + prompt)
+	if err != nil {
+		t.Fatalf("get artifact: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "## Task\n\nhello\n") {
+		t.Fatalf("unexpected artifact response: %d %q", resp.StatusCode, string(body))
+	}
+
+	// logs are served from the task's silicon log
+	resp, err = http.Get(srv.URL + "/v1/tasks/task-1/logs?tail=1")
+	if err != nil {
+		t.Fatalf("get logs: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "task completed") || strings.Count(string(body), "\n") != 1 {
+		t.Fatalf("unexpected logs tail: %q", string(body))
+	}
+
+	// missing artifact and path traversal
+	resp, _ = http.Get(srv.URL + "/v1/tasks/task-1/artifacts/nope.txt")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for missing artifact, got %d", resp.StatusCode)
+	}
+	resp, _ = http.Get(srv.URL + "/v1/tasks/task-1/artifacts/logs/..%2F..%2Fx")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for escaping path, got %d", resp.StatusCode)
+	}
+}
+
+func TestCreate_RejectsUnsafeTaskID(t *testing.T) {
+	srv := httptest.NewServer(newServer(artifacts.New(t.TempDir())))
+	defer srv.Close()
+
+	b, _ := json.Marshal(molecular.CreateTaskRequest{TaskID: "../escape", Prompt: "x"})
+	resp, err := http.Post(srv.URL+"/v1/tasks", "application/json", bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("post create: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestRun_PromptFailed(t *testing.T) {
+	s := newServer(artifacts.New(t.TempDir()))
+	for name, tmpl := range map[string]string{
+		"invalid":  "{{ .Prompt ",
+		"oversize": strings.Repeat("x", prompt.DefaultMaxBytes+1),
+	} {
+		repo := t.TempDir()
+		dir := filepath.Join(repo, filepath.FromSlash(prompt.Dir))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, prompt.RoleCarbon+".tmpl"), []byte(tmpl), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		st := &storedTask{t: molecular.Task{TaskID: name, Prompt: "p", Status: "running", RepoPath: repo}, ctx: ctx, cancel: cancel, created: time.Now()}
+		s.tasks[name] = st
+		s.run(st)
+
+		if st.t.Status != statusFailed || st.t.Phase != "done" || st.t.LatestAttempt != nil {
+			t.Fatalf("%s: expected the task failed before an attempt: %+v", name, st.t)
+		}
+		if e := st.t.Error; e == nil || e.Code != molecular.CodePromptFailed {
+			t.Fatalf("%s: unexpected error: %+v", name, st.t.Error)
+		}
+		if ev := st.events[len(st.events)-1]; ev.Type != molecular.EventTaskFailed || ev.Task.Error == nil {
+			t.Fatalf("%s: expected task.failed with the error, got %s", name, ev.Type)
+		}
+	}
+}
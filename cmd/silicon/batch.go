@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// batchOp applies one batch operation to a single task.
+type batchOp func(ctx context.Context, id string) molecular.BatchResult
+
+func (s *server) batchCancelOp(_ context.Context, id string) molecular.BatchResult {
+	t, aerr := s.cancelTask(id)
+	if aerr != nil {
+		return molecular.BatchResult{TaskID: id, Error: aerr}
+	}
+	return molecular.BatchResult{TaskID: id, Task: &t}
+}
+
+func (s *server) batchCleanupOp(ctx context.Context, id string) molecular.BatchResult {
+	res, aerr := s.cleanupTask(ctx, id)
+	if aerr != nil {
+		return molecular.BatchResult{TaskID: id, Error: aerr}
+	}
+	return molecular.BatchResult{TaskID: id, Cleanup: &res}
+}
+
+// batchRetryOp starts each retry fresh, in a new worktree and branch.
+func (s *server) batchRetryOp(ctx context.Context, id string) molecular.BatchResult {
+	t, aerr := s.retryTask(ctx, id, molecular.RetryRequest{Fresh: true})
+	if aerr != nil {
+		return molecular.BatchResult{TaskID: id, Error: aerr}
+	}
+	return molecular.BatchResult{TaskID: id, Task: &t}
+}
+
+// handleBatch serves POST /v1/tasks:batchCancel, :batchCleanup and
+// :batchRetry. The request picks tasks by ID or by selector and status;
+// op runs on each in turn and failures are reported per task, so the
+// response is 200 unless the request itself is invalid.
+func (s *server) handleBatch(name string, op batchOp) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req molecular.BatchRequest
+		if !decodeBody(w, r, &req) {
+			return
+		}
+		ids, aerr := s.selectBatch(req)
+		if aerr != nil {
+			writeAPIError(w, r, aerr)
+			return
+		}
+		resp := molecular.BatchResponse{Results: make([]molecular.BatchResult, 0, len(ids))}
+		for _, id := range ids {
+			resp.Results = append(resp.Results, op(r.Context(), id))
+		}
+		trace.SpanFromContext(r.Context()).SetAttributes(
+			attribute.String("silicon.batch.op", name),
+			attribute.Int("silicon.batch.tasks", len(ids)),
+			attribute.Int("silicon.batch.failed", len(resp.Failed())),
+		)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// selectBatch resolves a batch request to task IDs. Explicit IDs keep
+// their order, minus duplicates, and may name unknown tasks, which fail
+// individually; a selector or status picks existing tasks in ID order.
+func (s *server) selectBatch(req molecular.BatchRequest) ([]string, *molecular.Error) {
+	filtered := req.Selector != "" || req.Status != "" || req.All
+	if len(req.TaskIDs) > 0 {
+		if filtered {
+			return nil, apiError(http.StatusBadRequest, molecular.CodeInvalidRequest, "task_ids cannot be combined with selector, status or all", nil)
+		}
+		seen := make(map[string]bool, len(req.TaskIDs))
+		var ids []string
+		for _, id := range req.TaskIDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		return ids, nil
+	}
+	if !filtered {
+		return nil, apiError(http.StatusBadRequest, molecular.CodeInvalidRequest, "select tasks with task_ids, selector, status or all", nil)
+	}
+	sel, err := molecular.ParseSelector(req.Selector)
+	if err != nil {
+		return nil, apiError(http.StatusBadRequest, molecular.CodeInvalidRequest, "selector: "+err.Error(), nil)
+	}
+	q := listQuery{status: req.Status, selector: sel}
+
+	s.mu.Lock()
+	var ids []string
+	for id, st := range s.tasks {
+		if q.match(st.t) {
+			ids = append(ids, id)
+		}
+	}
+	s.mu.Unlock()
+	sort.Strings(ids)
+	return ids, nil
+}
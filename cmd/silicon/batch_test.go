@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// waitFinished waits until the task has emitted its terminal event.
+func waitFinished(t *testing.T, s *server, id string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mu.Lock()
+		done := finishedLocked(s.tasks[id])
+		s.mu.Unlock()
+		if done {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("task %s did not finish in time", id)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCleanupRetryAndBatch(t *testing.T) {
+	repo, _ := gitRepo(t)
+	state := t.TempDir()
+	s := newServer(artifacts.New(filepath.Join(state, "tasks")))
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	post := func(path string, body any, v any) int {
+		t.Helper()
+		b, _ := json.Marshal(body)
+		resp, err := http.Post(srv.URL+path, "application/json", bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("post %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		if v != nil {
+			_ = json.NewDecoder(resp.Body).Decode(v)
+		}
+		return resp.StatusCode
+	}
+
+	billing := map[string]string{"epic": "billing"}
+	for _, req := range []molecular.CreateTaskRequest{
+		{TaskID: "t1", Prompt: "one", RepoPath: repo, Labels: billing, Metadata: map[string]string{"ticket": "BIL-1"}},
+		{TaskID: "t2", Prompt: "two", Labels: billing},
+		{TaskID: "t3", Prompt: "three"},
+	} {
+		if code := post("/v1/tasks", req, nil); code != http.StatusCreated {
+			t.Fatalf("create %s: %d", req.TaskID, code)
+		}
+		waitFinished(t, s, req.TaskID)
+	}
+
+	// cleanup removes the worktree and artifacts but keeps the branch
+	var res molecular.CleanupResult
+	if code := post("/v1/tasks/t1/cleanup", nil, &res); code != http.StatusOK || res != (molecular.CleanupResult{TaskID: "t1", Artifacts: true, Worktree: true}) {
+		t.Fatalf("cleanup: %d %+v", code, res)
+	}
+	if _, err := os.Stat(filepath.Join(state, "worktrees", "t1")); !os.IsNotExist(err) {
+		t.Fatalf("worktree still present: %v", err)
+	}
+	if _, err := os.Stat(s.store.TaskDir("t1")); !os.IsNotExist(err) {
+		t.Fatalf("artifacts still present: %v", err)
+	}
+	gitRun(t, repo, "rev-parse", "--verify", "molecular/t1")
+	if code := post("/v1/tasks/t1/cleanup", nil, &res); code != http.StatusOK || res.Artifacts || res.Worktree {
+		t.Fatalf("second cleanup: %d %+v", code, res)
+	}
+
+	s.mu.Lock()
+	s.tasks["busy"] = &storedTask{t: molecular.Task{TaskID: "busy", Status: "running"}, cancel: func() {}}
+	s.mu.Unlock()
+	var e molecular.Error
+	if code := post("/v1/tasks/busy/cleanup", nil, &e); code != http.StatusConflict || e.Code != molecular.CodeTaskRunning {
+		t.Fatalf("cleanup running task: %d %+v", code, e)
+	}
+
+	// retries copy the inputs and link back to the original
+	var batch molecular.BatchResponse
+	if code := post("/v1/tasks:batchRetry", molecular.BatchRequest{Selector: "epic=billing"}, &batch); code != http.StatusOK || len(batch.Results) != 2 {
+		t.Fatalf("batch retry: %d %+v", code, batch)
+	}
+	r1 := batch.Results[0]
+	if r1.TaskID != "t1" || r1.Task == nil || r1.Task.TaskID != "t1-retry-1" || r1.Task.RetryOf != "t1" ||
+		r1.Task.Prompt != "one" || r1.Task.Metadata["ticket"] != "BIL-1" || r1.Task.BranchName != "molecular/t1-retry-1" {
+		t.Fatalf("unexpected retry of t1: %+v", r1.Task)
+	}
+	if batch.Results[1].Task == nil || batch.Results[1].Task.TaskID != "t2-retry-1" {
+		t.Fatalf("unexpected retry of t2: %+v", batch.Results[1])
+	}
+	waitFinished(t, s, "t1-retry-1")
+	if post("/v1/tasks:batchRetry", molecular.BatchRequest{TaskIDs: []string{"t1"}}, &batch); batch.Results[0].Task.TaskID != "t1-retry-2" {
+		t.Fatalf("second retry: %+v", batch.Results[0])
+	}
+
+	// failures are per task; duplicate IDs run once
+	batch = molecular.BatchResponse{}
+	if code := post("/v1/tasks:batchCancel", molecular.BatchRequest{TaskIDs: []string{"t3", "nope", "t3", "busy"}}, &batch); code != http.StatusOK || len(batch.Results) != 3 {
+		t.Fatalf("batch cancel: %d %+v", code, batch)
+	}
+	if batch.Results[0].Error.Code != molecular.CodeTaskNotRunning || batch.Results[1].Error.Code != molecular.CodeNotFound ||
+		batch.Results[2].Task == nil || batch.Results[2].Task.Status != "cancelled" || len(batch.Failed()) != 2 {
+		t.Fatalf("batch cancel results: %+v", batch.Results)
+	}
+
+	batch = molecular.BatchResponse{}
+	if code := post("/v1/tasks:batchCleanup", molecular.BatchRequest{Status: "completed"}, &batch); code != http.StatusOK || len(batch.Results) < 3 {
+		t.Fatalf("batch cleanup: %d %+v", code, batch)
+	}
+	for _, r := range batch.Results {
+		if r.Error != nil && r.Error.Code != molecular.CodeTaskRunning {
+			t.Fatalf("batch cleanup %s: %+v", r.TaskID, r.Error)
+		}
+	}
+
+	for _, bad := range []molecular.BatchRequest{{}, {TaskIDs: []string{"t1"}, All: true}, {Selector: "a b"}} {
+		if code := post("/v1/tasks:batchCancel", bad, &e); code != http.StatusBadRequest || e.Code != molecular.CodeInvalidRequest {
+			t.Fatalf("%+v: expected 400, got %d %+v", bad, code, e)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/throw-if-null/molecular/internal/git"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// finishedLocked reports whether st has emitted its terminal event, after
+// which run no longer touches its artifacts. Callers must hold s.mu.
+func finishedLocked(st *storedTask) bool {
+	n := len(st.events)
+	return n > 0 && st.events[n-1].Terminal()
+}
+
+func (s *server) handleCleanup(w http.ResponseWriter, r *http.Request, id string) {
+	res, aerr := s.cleanupTask(r.Context(), id)
+	if aerr != nil {
+		writeAPIError(w, r, aerr)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// cleanupTask removes a finished task's worktree and artifacts. The task
+// record and its branch are kept; cleaning up twice removes nothing. A
+// worktree claimed by a retry is left to the retry.
+func (s *server) cleanupTask(ctx context.Context, id string) (molecular.CleanupResult, *molecular.Error) {
+	res := molecular.CleanupResult{TaskID: id}
+	s.mu.Lock()
+	st, ok := s.tasks[id]
+	if !ok {
+		s.mu.Unlock()
+		return res, errTaskNotFound(id)
+	}
+	if !finishedLocked(st) {
+		status := st.t.Status
+		s.mu.Unlock()
+		return res, apiError(http.StatusConflict, molecular.CodeTaskRunning, "task is still running", map[string]any{"task_id": id, "status": status})
+	}
+	repo, worktree, root := st.t.RepoPath, st.t.WorktreePath, st.t.ArtifactsRoot
+	if st.t.ClaimedBy != "" {
+		worktree = ""
+	}
+	s.mu.Unlock()
+
+	if worktree != "" {
+		if err := git.RemoveWorktree(ctx, repo, worktree); err != nil {
+			msg := err.Error()
+			var gerr *git.Error
+			if errors.As(err, &gerr) {
+				msg = gerr.Stderr
+			}
+			return res, apiError(http.StatusInternalServerError, molecular.CodeInternal, "removing worktree: "+msg, map[string]any{"task_id": id})
+		}
+		res.Worktree = true
+	}
+	if root != "" {
+		if err := s.store.Remove(id); err != nil {
+			return res, apiError(http.StatusInternalServerError, molecular.CodeInternal, "removing artifacts", map[string]any{"task_id": id})
+		}
+		res.Artifacts = true
+	}
+
+	s.mu.Lock()
+	if res.Worktree {
+		st.t.WorktreePath = ""
+		// the tasks this one continued from shared the worktree
+		for claimer, prev := id, st.t.RetryOf; prev != ""; {
+			orig, ok := s.tasks[prev]
+			if !ok || orig.t.ClaimedBy != claimer {
+				break
+			}
+			orig.t.WorktreePath = ""
+			claimer, prev = prev, orig.t.RetryOf
+		}
+	}
+	st.t.ArtifactsRoot = ""
+	st.t.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	s.mu.Unlock()
+	return res, nil
+}
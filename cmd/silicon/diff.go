@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/throw-if-null/molecular/internal/git"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// handleDiff serves the changes in a task's worktree relative to the commit
+// the worktree was created from. Query parameters:
+//
+//	stat=true     diffstat summary instead of the full diff
+//	format=patch  commits since the base as `git am`-able mbox output
+func (s *server) handleDiff(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	st, ok := s.tasks[id]
+	var worktree, base string
+	if ok {
+		worktree, base = st.t.WorktreePath, st.t.BaseCommit
+	}
+	s.mu.Unlock()
+	if !ok {
+		taskNotFound(w, r, id)
+		return
+	}
+	if worktree == "" || base == "" {
+		writeError(w, r, http.StatusConflict, molecular.CodeNoWorktree, "task has no worktree", map[string]any{"task_id": id})
+		return
+	}
+
+	q := r.URL.Query()
+	var (
+		out []byte
+		err error
+	)
+	switch q.Get("format") {
+	case "", "diff":
+		out, err = git.Diff(r.Context(), worktree, base, q.Get("stat") == "true")
+	case "patch":
+		out, err = git.FormatPatch(r.Context(), worktree, base)
+	default:
+		writeError(w, r, http.StatusBadRequest, molecular.CodeInvalidRequest, "unknown format", map[string]any{"format": q.Get("format")})
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, molecular.CodeInternal, err.Error(), nil)
+		return
+	}
+	w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(out)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// gitRepo creates a repository with one commit and returns its path and
+// HEAD commit.
+func gitRepo(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not in PATH")
+	}
+	dir := t.TempDir()
+	gitRun(t, dir, "init", "-q", "-b", "main")
+	gitRun(t, dir, "config", "user.email", "test@example.com")
+	gitRun(t, dir, "config", "user.name", "test")
+	gitRun(t, dir, "config", "commit.gpgsign", "false")
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-q", "-m", "initial")
+	return dir, gitRun(t, dir, "rev-parse", "HEAD")
+}
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestDiff_Endpoint(t *testing.T) {
+	repo, base := gitRepo(t)
+	if err := os.WriteFile(filepath.Join(repo, "a.txt"), []byte("two\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, repo, "commit", "-q", "-am", "change a")
+
+	s := newServer(artifacts.New(t.TempDir()))
+	s.tasks["task-1"] = &storedTask{t: molecular.Task{TaskID: "task-1", WorktreePath: repo, BaseCommit: base}}
+	s.tasks["task-2"] = &storedTask{t: molecular.Task{TaskID: "task-2"}}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("get %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(b)
+	}
+
+	code, body := get("/v1/tasks/task-1/diff")
+	if code != http.StatusOK || !strings.Contains(body, "+two") {
+		t.Fatalf("diff: %d %q", code, body)
+	}
+	code, body = get("/v1/tasks/task-1/diff?stat=true")
+	if code != http.StatusOK || !strings.Contains(body, "1 file changed") {
+		t.Fatalf("diff stat: %d %q", code, body)
+	}
+	code, body = get("/v1/tasks/task-1/diff?format=patch")
+	if code != http.StatusOK || !strings.Contains(body, "Subject: [PATCH] change a") {
+		t.Fatalf("patch: %d %q", code, body)
+	}
+	if code, _ = get("/v1/tasks/task-1/diff?format=zip"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown format, got %d", code)
+	}
+	if code, _ = get("/v1/tasks/task-2/diff"); code != http.StatusConflict {
+		t.Fatalf("expected 409 without worktree, got %d", code)
+	}
+	if code, _ = get("/v1/tasks/nope/diff"); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown task, got %d", code)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// writeError sends the standard JSON error envelope and tags the request
+// span with the error code.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code molecular.ErrorCode, msg string, details map[string]any) {
+	body := molecular.Error{Code: code, Message: msg, Details: details}
+	span := trace.SpanFromContext(r.Context())
+	if sc := span.SpanContext(); sc.HasTraceID() {
+		body.TraceID = sc.TraceID().String()
+	}
+	span.SetAttributes(attribute.String("silicon.error.code", string(code)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// apiError builds the error an operation shared by several handlers
+// returns; StatusCode carries the HTTP status for writeAPIError.
+func apiError(status int, code molecular.ErrorCode, msg string, details map[string]any) *molecular.Error {
+	return &molecular.Error{Code: code, Message: msg, Details: details, StatusCode: status}
+}
+
+// writeAPIError sends an error built by apiError.
+func writeAPIError(w http.ResponseWriter, r *http.Request, e *molecular.Error) {
+	writeError(w, r, e.StatusCode, e.Code, e.Message, e.Details)
+}
+
+// errTaskNotFound is the error for an unknown task ID.
+func errTaskNotFound(id string) *molecular.Error {
+	return apiError(http.StatusNotFound, molecular.CodeNotFound, "task not found", map[string]any{"task_id": id})
+}
+
+// taskNotFound reports an unknown task ID.
+func taskNotFound(w http.ResponseWriter, r *http.Request, id string) {
+	writeAPIError(w, r, errTaskNotFound(id))
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/internal/telemetry"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+func TestErrors_JSONEnvelope(t *testing.T) {
+	useTestTracer(t)
+	if _, err := telemetryInit(context.Background(), telemetry.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(withTracing(newServer(artifacts.New(t.TempDir()))))
+	defer srv.Close()
+
+	call := func(method, path, body string) (int, molecular.Error) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, bytes.NewBufferString(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		var e molecular.Error
+		if resp.StatusCode >= 400 {
+			if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+				t.Fatalf("%s %s: expected JSON error, got %q", method, path, ct)
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&e); err != nil {
+				t.Fatalf("%s %s: decode error body: %v", method, path, err)
+			}
+		}
+		return resp.StatusCode, e
+	}
+
+	code, e := call(http.MethodGet, "/v1/tasks/nope", "")
+	if code != http.StatusNotFound || e.Code != molecular.CodeNotFound || e.Details["task_id"] != "nope" {
+		t.Fatalf("unknown task: %d %+v", code, e)
+	}
+	if len(e.TraceID) != 32 {
+		t.Fatalf("expected trace_id in error, got %q", e.TraceID)
+	}
+
+	if code, e = call(http.MethodPost, "/v1/tasks", "{"); code != http.StatusBadRequest || e.Code != molecular.CodeInvalidRequest {
+		t.Fatalf("bad json: %d %+v", code, e)
+	}
+	if code, e = call(http.MethodPost, "/v1/tasks", `{"prompt":"p","labels":{"epic":"bad value"}}`); code != http.StatusBadRequest || e.Code != molecular.CodeInvalidRequest {
+		t.Fatalf("bad label: %d %+v", code, e)
+	}
+	if code, e = call(http.MethodGet, "/v2/whatever", ""); code != http.StatusNotFound || e.Code != molecular.CodeNotFound {
+		t.Fatalf("unknown route: %d %+v", code, e)
+	}
+
+	if code, _ = call(http.MethodPost, "/v1/tasks", `{"task_id":"t1","prompt":"p"}`); code != http.StatusCreated {
+		t.Fatalf("create: %d", code)
+	}
+	if code, e = call(http.MethodPost, "/v1/tasks", `{"task_id":"t1","prompt":"p"}`); code != http.StatusConflict || e.Code != molecular.CodeTaskExists {
+		t.Fatalf("duplicate: %d %+v", code, e)
+	}
+
+	waitTerminal(t, srv.URL, "t1")
+	if code, e = call(http.MethodPost, "/v1/tasks/t1/cancel", ""); code != http.StatusConflict || e.Code != molecular.CodeTaskNotRunning {
+		t.Fatalf("cancel finished task: %d %+v", code, e)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// emitLocked appends a lifecycle event to st and wakes everyone streaming
+// it. Callers must hold s.mu.
+func (s *server) emitLocked(st *storedTask, typ string) {
+	st.events = append(st.events, molecular.Event{
+		Seq:  int64(len(st.events)) + 1,
+		Time: time.Now().UTC().Format(time.RFC3339Nano),
+		Type: typ,
+		Task: snapshotTask(st.t),
+	})
+	if st.wake != nil {
+		close(st.wake)
+	}
+	st.wake = make(chan struct{})
+}
+
+// snapshotTask deep-copies t so an event keeps the task as it was when
+// the event happened, whatever later changes the task in place.
+func snapshotTask(t molecular.Task) molecular.Task {
+	if t.PromptTemplate != nil {
+		pt := *t.PromptTemplate
+		pt.Vars = maps.Clone(pt.Vars)
+		t.PromptTemplate = &pt
+	}
+	if t.CurrentAttemptID != nil {
+		id := *t.CurrentAttemptID
+		t.CurrentAttemptID = &id
+	}
+	if t.LatestAttempt != nil {
+		a := *t.LatestAttempt
+		a.Feedback = slices.Clone(a.Feedback)
+		if a.Usage != nil {
+			u := *a.Usage
+			a.Usage = &u
+		}
+		t.LatestAttempt = &a
+	}
+	t.Labels = maps.Clone(t.Labels)
+	t.Metadata = maps.Clone(t.Metadata)
+	t.Feedback = slices.Clone(t.Feedback)
+	if t.Usage != nil {
+		u := *t.Usage
+		t.Usage = &u
+	}
+	t.UsageByModel = slices.Clone(t.UsageByModel)
+	if t.Error != nil {
+		e := *t.Error
+		e.Details = maps.Clone(e.Details)
+		t.Error = &e
+	}
+	return t
+}
+
+// waitLocked returns st's events after seq, whether the last of them ends
+// the stream, and a channel closed when more arrive. Callers must hold
+// s.mu.
+func (s *server) waitLocked(st *storedTask, after int64) ([]molecular.Event, bool, <-chan struct{}) {
+	if st.wake == nil {
+		st.wake = make(chan struct{})
+	}
+	var out []molecular.Event
+	if after < int64(len(st.events)) {
+		out = append(out, st.events[max(after, 0):]...)
+	}
+	done := len(st.events) > 0 && st.events[len(st.events)-1].Terminal()
+	return out, done, st.wake
+}
+
+// handleEvents streams a task's events as newline-delimited JSON: first
+// those after ?after=N, then new ones as they happen, until the task's
+// terminal event or the client goes away.
+func (s *server) handleEvents(w http.ResponseWriter, r *http.Request, id string) {
+	var after int64
+	if v := r.URL.Query().Get("after"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			writeError(w, r, http.StatusBadRequest, molecular.CodeInvalidRequest, "invalid after", map[string]any{"after": v})
+			return
+		}
+		after = n
+	}
+	s.mu.Lock()
+	st, ok := s.tasks[id]
+	s.mu.Unlock()
+	if !ok {
+		taskNotFound(w, r, id)
+		return
+	}
+
+	streamResponse(w)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	for {
+		s.mu.Lock()
+		events, done, wake := s.waitLocked(st, after)
+		s.mu.Unlock()
+		for _, e := range events {
+			if err := enc.Encode(e); err != nil {
+				return
+			}
+			after = e.Seq
+		}
+		_ = rc.Flush()
+		if done {
+			return
+		}
+		select {
+		case <-wake:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+func TestEventsAndFollowLogs(t *testing.T) {
+	srv := httptest.NewServer(newServer(artifacts.New(t.TempDir())))
+	defer srv.Close()
+	c := molecular.New(srv.URL, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := c.CreateTask(ctx, molecular.CreateTaskRequest{TaskID: "task-1", Prompt: "p"}, molecular.CreateTaskOptions{}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	final, err := c.Wait(ctx, "task-1")
+	if err != nil || final.Status != "completed" {
+		t.Fatalf("wait: %+v %v", final, err)
+	}
+
+	// a finished task replays its history and closes the stream
+	stream, err := c.Events(ctx, "task-1", 0)
+	if err != nil {
+		t.Fatalf("events: %v", err)
+	}
+	var types []string
+	for {
+		e, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next: %v", err)
+		}
+		if e.Seq != int64(len(types))+1 || e.Task.TaskID != "task-1" {
+			t.Fatalf("unexpected event: %+v", e)
+		}
+		types = append(types, e.Type)
+		// each event carries the task as it was then
+		switch a := e.Task.LatestAttempt; e.Type {
+		case molecular.EventAttemptStarted:
+			if a == nil || a.Status != "running" || a.FinishedAt != "" || e.Task.Status != "running" {
+				t.Fatalf("attempt.started payload: task %s, attempt %+v", e.Task.Status, a)
+			}
+		case molecular.EventAttemptFinished:
+			if a == nil || a.Status != "completed" || a.FinishedAt == "" {
+				t.Fatalf("attempt.finished payload: %+v", a)
+			}
+		}
+	}
+	stream.Close()
+	want := []string{molecular.EventTaskCreated, molecular.EventAttemptStarted, molecular.EventAttemptFinished, molecular.EventTaskCompleted}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Fatalf("events: got %v want %v", types, want)
+	}
+
+	// after skips what the caller has already seen
+	stream, err = c.Events(ctx, "task-1", 3)
+	if err != nil {
+		t.Fatalf("events after: %v", err)
+	}
+	if e, err := stream.Next(); err != nil || e.Seq != 4 {
+		t.Fatalf("events after 3: %+v %v", e, err)
+	}
+	stream.Close()
+
+	logs, err := c.Logs(ctx, "task-1", molecular.LogsOptions{Follow: true})
+	if err != nil {
+		t.Fatalf("logs: %v", err)
+	}
+	b, _ := io.ReadAll(logs)
+	logs.Close()
+	if !strings.HasSuffix(string(b), "task completed\n") {
+		t.Fatalf("followed log missing final line: %q", b)
+	}
+
+	if _, err := c.Events(ctx, "nope", 0); !errors.Is(err, molecular.ErrNotFound) {
+		t.Fatalf("expected not_found, got %v", err)
+	}
+}
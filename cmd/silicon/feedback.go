@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// handleFeedback serves POST /v1/tasks/{id}/feedback. The note is kept on
+// the task until the next Carbon attempt starts, which includes it in its
+// prompt; notes still pending when the task finishes go to its retry.
+func (s *server) handleFeedback(w http.ResponseWriter, r *http.Request, id string) {
+	var req molecular.FeedbackRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		writeError(w, r, http.StatusBadRequest, molecular.CodeInvalidRequest, "message required", nil)
+		return
+	}
+
+	s.mu.Lock()
+	st, ok := s.tasks[id]
+	if !ok {
+		s.mu.Unlock()
+		taskNotFound(w, r, id)
+		return
+	}
+	if st.t.Status != "running" {
+		status := st.t.Status
+		s.mu.Unlock()
+		writeError(w, r, http.StatusConflict, molecular.CodeTaskNotRunning, "task is not running", map[string]any{"task_id": id, "status": status})
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	st.t.Feedback = append(st.t.Feedback, molecular.Feedback{Message: req.Message, Author: req.Author, CreatedAt: now})
+	st.t.UpdatedAt = now
+	resp := st.t
+	s.mu.Unlock()
+
+	trace.SpanFromContext(r.Context()).AddEvent("decision.human_feedback", trace.WithAttributes(feedbackAttributes(id, req.Author)...))
+	s.logf(id, "feedback received")
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// feedbackAttributes describe a feedback note on span events. The message
+// itself is left out, as prompts are.
+func feedbackAttributes(taskID, author string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{attribute.String("task.id", taskID)}
+	if author != "" {
+		attrs = append(attrs, attribute.String("silicon.feedback.author", author))
+	}
+	return attrs
+}
+
+// pendingFeedbackLocked returns st's feedback that no attempt has
+// included and no retry has taken yet, stamped with attempt num. Callers
+// must hold s.mu.
+func pendingFeedbackLocked(st *storedTask, num int64) []molecular.Feedback {
+	var pending []molecular.Feedback
+	for _, f := range st.t.Feedback {
+		if f.Pending() {
+			f.Attempt = num
+			pending = append(pending, f)
+		}
+	}
+	return pending
+}
+
+// markFeedbackLocked records that attempt num included the first n
+// pending notes. Feedback is only ever appended, so these are the notes
+// pendingFeedbackLocked returned. Callers must hold s.mu.
+func markFeedbackLocked(st *storedTask, num int64, n int) {
+	if n == 0 {
+		return
+	}
+	// copy first: task snapshots handed out earlier share the array
+	fb := slices.Clone(st.t.Feedback)
+	for i := range fb {
+		if n > 0 && fb[i].Pending() {
+			fb[i].Attempt = num
+			n--
+		}
+	}
+	st.t.Feedback = fb
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestFeedback(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(prev)
+
+	s := newServer(artifacts.New(t.TempDir()))
+	srv := httptest.NewServer(withTracing(s))
+	defer srv.Close()
+
+	// a task parked between attempts
+	st := &storedTask{t: molecular.Task{TaskID: "t1", Prompt: "fix the login bug\n", Status: "running"}, cancel: func() {}}
+	s.tasks["t1"] = st
+	if _, err := s.store.CreateTask("t1"); err != nil {
+		t.Fatal(err)
+	}
+
+	feedback := func(id, body string, v any) int {
+		t.Helper()
+		resp, err := http.Post(srv.URL+"/v1/tasks/"+id+"/feedback", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("feedback: %v", err)
+		}
+		defer resp.Body.Close()
+		_ = json.NewDecoder(resp.Body).Decode(v)
+		return resp.StatusCode
+	}
+
+	var e molecular.Error
+	if code := feedback("t1", `{"message":"  "}`, &e); code != http.StatusBadRequest || e.Code != molecular.CodeInvalidRequest {
+		t.Fatalf("blank message: %d %+v", code, e)
+	}
+	if code := feedback("nope", `{"message":"x"}`, &e); code != http.StatusNotFound {
+		t.Fatalf("unknown task: %d", code)
+	}
+	var task molecular.Task
+	if code := feedback("t1", `{"message":"keep the old endpoint","author":"ana"}`, &task); code != http.StatusOK || len(task.Feedback) != 1 || task.Feedback[0].Attempt != 0 {
+		t.Fatalf("feedback: %d %+v", code, task)
+	}
+	feedback("t1", `{"message":"add a test\nfor the redirect"}`, &task)
+
+	var events int
+	for _, sp := range exp.GetSpans() {
+		for _, ev := range sp.Events {
+			if ev.Name == "decision.human_feedback" {
+				events++
+			}
+		}
+	}
+	if events != 2 {
+		t.Fatalf("expected 2 decision.human_feedback events, got %d", events)
+	}
+
+	// only Carbon attempts take the pending notes
+	if _, err := s.startAttempt(st, "helium"); err != nil {
+		t.Fatal(err)
+	}
+	a, err := s.startAttempt(st, "carbon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Feedback) != 2 || a.Feedback[0].Attempt != 2 {
+		t.Fatalf("attempt feedback: %+v", a.Feedback)
+	}
+	prompt, err := os.ReadFile(filepath.Join(a.ArtifactsDir, artifacts.DirPrompts, "carbon.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "## Reviewer feedback\n\nA human reviewer added these notes while you worked. Follow them.\n\n- ana: keep the old endpoint\n- add a test\n  for the redirect\n"
+	if !strings.HasSuffix(string(prompt), want) {
+		t.Fatalf("prompt:\n%s\nwant suffix:\n%s", prompt, want)
+	}
+
+	feedback("t1", `{"message":"one more thing"}`, &task)
+	if len(task.Feedback) != 3 || task.Feedback[1].Attempt != 2 || task.Feedback[2].Attempt != 0 {
+		t.Fatalf("feedback history: %+v", task.Feedback)
+	}
+	a, _ = s.startAttempt(st, "carbon")
+	prompt, _ = os.ReadFile(filepath.Join(a.ArtifactsDir, artifacts.DirPrompts, "carbon.md"))
+	if strings.Contains(string(prompt), "keep the old endpoint") || !strings.Contains(string(prompt), "- one more thing\n") {
+		t.Fatalf("second carbon prompt repeats or misses feedback:\n%s", prompt)
+	}
+
+	s.mu.Lock()
+	st.t.Status = "completed"
+	s.mu.Unlock()
+	if code := feedback("t1", `{"message":"too late"}`, &e); code != http.StatusConflict || e.Code != molecular.CodeTaskNotRunning {
+		t.Fatalf("finished task: %d %+v", code, e)
+	}
+}
+
+func TestFeedbackCarriedIntoRetryPrompt(t *testing.T) {
+	s := newServer(artifacts.New(t.TempDir()))
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	post := func(path, body string, v any) int {
+		t.Helper()
+		resp, err := http.Post(srv.URL+path, "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("post %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		_ = json.NewDecoder(resp.Body).Decode(v)
+		return resp.StatusCode
+	}
+
+	// the note arrives after the task's Carbon attempt rendered its prompt
+	st := &storedTask{t: molecular.Task{TaskID: "t1", Prompt: "fix the login bug\n", Status: "running"}, cancel: func() {}}
+	s.tasks["t1"] = st
+	if _, err := s.store.CreateTask("t1"); err != nil {
+		t.Fatal(err)
+	}
+	first, err := s.startAttempt(st, "carbon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var task molecular.Task
+	if code := post("/v1/tasks/t1/feedback", `{"message":"keep the old endpoint","author":"ana"}`, &task); code != http.StatusOK {
+		t.Fatalf("feedback: %d", code)
+	}
+	s.mu.Lock()
+	st.t.Status = "completed"
+	s.emitLocked(st, molecular.EventTaskCompleted)
+	s.mu.Unlock()
+
+	var retried molecular.Task
+	if code := post("/v1/tasks/t1/retry", ``, &retried); code != http.StatusCreated {
+		t.Fatalf("retry: %d", code)
+	}
+	if len(retried.Feedback) != 1 || retried.Feedback[0].Attempt != 0 {
+		t.Fatalf("retry feedback: %+v", retried.Feedback)
+	}
+	waitFinished(t, s, retried.TaskID)
+
+	s.mu.Lock()
+	done := s.tasks[retried.TaskID].t
+	s.mu.Unlock()
+	if len(done.Feedback) != 1 || done.Feedback[0].Attempt != 1 || len(done.LatestAttempt.Feedback) != 1 {
+		t.Fatalf("retry did not take the note: %+v", done)
+	}
+	prompt, err := os.ReadFile(filepath.Join(done.LatestAttempt.ArtifactsDir, artifacts.DirPrompts, "carbon.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(prompt), "## Reviewer feedback") || !strings.Contains(string(prompt), "- ana: keep the old endpoint\n") {
+		t.Fatalf("retry prompt lacks the note:\n%s", prompt)
+	}
+	original, _ := os.ReadFile(filepath.Join(first.ArtifactsDir, artifacts.DirPrompts, "carbon.md"))
+	if strings.Contains(string(original), "keep the old endpoint") {
+		t.Fatalf("note leaked into the already rendered prompt")
+	}
+
+	// the original records who took the note, so a second retry does not
+	// get it again
+	s.mu.Lock()
+	orig := s.tasks["t1"].t
+	s.mu.Unlock()
+	if len(orig.Feedback) != 1 || orig.Feedback[0].TakenBy != retried.TaskID || orig.Feedback[0].Pending() {
+		t.Fatalf("original feedback: %+v", orig.Feedback)
+	}
+	var again molecular.Task
+	if code := post("/v1/tasks/t1/retry", `{"fresh":true}`, &again); code != http.StatusCreated {
+		t.Fatalf("second retry: %d", code)
+	}
+	if len(again.Feedback) != 0 {
+		t.Fatalf("second retry took the note again: %+v", again.Feedback)
+	}
+}
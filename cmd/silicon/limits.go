@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/throw-if-null/molecular/internal/config"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// statusBudgetExceeded is the status of a task stopped by a cap.
+const statusBudgetExceeded = "budget_exceeded"
+
+// ledger totals what agents consumed on one UTC day, for the daily caps.
+type ledger struct {
+	day      string
+	tokens   int64
+	costUSD  float64
+	duration time.Duration
+}
+
+// dailyLocked returns the ledger for now's day, starting an empty one at
+// midnight UTC. Callers must hold s.mu.
+func (s *server) dailyLocked(now time.Time) *ledger {
+	if day := now.UTC().Format(time.DateOnly); s.daily.day != day {
+		s.daily = ledger{day: day}
+	}
+	return &s.daily
+}
+
+// add adds an attempt's usage and running time to the ledger.
+func (l *ledger) add(u *molecular.Usage, d time.Duration) {
+	if u != nil {
+		l.tokens += u.InputTokens + u.OutputTokens
+		l.costUSD += u.CostUSD
+	}
+	l.duration += d
+}
+
+// dailyCapLocked returns the error for the first daily cap already
+// reached, or nil. Callers must hold s.mu.
+func (s *server) dailyCapLocked(now time.Time) *molecular.Error {
+	l := s.dailyLocked(now)
+	return capReached("daily", s.limits.Daily, l.tokens, l.costUSD, l.duration)
+}
+
+// budgetLocked returns the error for the first task or daily cap that
+// stops st from starting another attempt, or nil. The task caps count the
+// usage of the tasks st retries. Callers must hold s.mu.
+func (s *server) budgetLocked(st *storedTask, now time.Time) *molecular.Error {
+	used := st.prior
+	if u := st.t.Usage; u != nil {
+		used.Add(*u)
+	}
+	tokens, cost := used.InputTokens+used.OutputTokens, used.CostUSD
+	if err := capReached("task", s.limits.Task, tokens, cost, now.Sub(st.created)); err != nil {
+		return err
+	}
+	return s.dailyCapLocked(now)
+}
+
+// capReached returns a budget_exceeded error naming the first of caps
+// that the amounts used reach, or nil. scope prefixes the cap's name as
+// in the config file: "task" or "daily".
+func capReached(scope string, caps config.Caps, tokens int64, cost float64, d time.Duration) *molecular.Error {
+	switch {
+	case caps.MaxTokens > 0 && tokens >= caps.MaxTokens:
+		return errCap(scope+".max_tokens", caps.MaxTokens, tokens)
+	case caps.MaxCostUSD > 0 && cost >= caps.MaxCostUSD:
+		return errCap(scope+".max_cost_usd", caps.MaxCostUSD, cost)
+	case caps.MaxDuration > 0 && d >= caps.MaxDuration:
+		return errCap(scope+".max_duration", caps.MaxDuration.String(), d.Round(time.Second).String())
+	}
+	return nil
+}
+
+// errCap is the budget_exceeded error for a cap. It is a 429 when it
+// refuses a request.
+func errCap(name string, limit, used any) *molecular.Error {
+	return apiError(http.StatusTooManyRequests, molecular.CodeBudgetExceeded, fmt.Sprintf("%s cap reached", name),
+		map[string]any{"cap": name, "limit": limit, "used": used})
+}
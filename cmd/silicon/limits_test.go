@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/internal/config"
+	"github.com/throw-if-null/molecular/internal/task"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+func TestRun_TaskCaps(t *testing.T) {
+	s := newServer(artifacts.New(t.TempDir()))
+	s.limits.Task = config.Caps{MaxTokens: 100, MaxCostUSD: 10, MaxDuration: time.Hour}
+	// every attempt's agent reports 60 tokens
+	s.runner = task.Runner{Command: `echo '{"input_tokens":50,"output_tokens":10,"cost_usd":0.5}'`}
+	ctx := context.Background()
+
+	// retries count what the tasks they retry used, so attempts run until
+	// the chain reaches the cap
+	id := "tokens"
+	if _, _, aerr := s.createTask(ctx, molecular.CreateTaskRequest{TaskID: id, Prompt: "p"}, "", nil); aerr != nil {
+		t.Fatal(aerr)
+	}
+	var statuses []molecular.TaskStatus
+	for i := 0; i < 3; i++ {
+		waitFinished(t, s, id)
+		s.mu.Lock()
+		statuses = append(statuses, s.tasks[id].t.Status)
+		s.mu.Unlock()
+		next, aerr := s.retryTask(ctx, id, molecular.RetryRequest{Fresh: true})
+		if aerr != nil {
+			t.Fatal(aerr)
+		}
+		id = next.TaskID
+	}
+	if want := []molecular.TaskStatus{"completed", "completed", statusBudgetExceeded}; !slices.Equal(statuses, want) {
+		t.Fatalf("statuses along the retries: %v, want %v", statuses, want)
+	}
+	s.mu.Lock()
+	stopped := s.tasks["tokens-retry-1-retry-1"].t
+	s.mu.Unlock()
+	if stopped.LatestAttempt != nil {
+		t.Fatalf("expected the task stopped before an attempt: %+v", stopped)
+	}
+	if e := stopped.Error; e == nil || e.Code != molecular.CodeBudgetExceeded || e.Details["cap"] != "task.max_tokens" || e.Details["used"] != int64(120) {
+		t.Fatalf("unexpected error: %+v", stopped.Error)
+	}
+	s.mu.Lock()
+	ev := s.tasks["tokens-retry-1-retry-1"].events
+	s.mu.Unlock()
+	if last := ev[len(ev)-1]; last.Type != molecular.EventTaskFailed || !last.Terminal() {
+		t.Fatalf("expected a terminal task.failed event, got %s", last.Type)
+	}
+
+	// the cost cap trips the same way
+	s.limits.Task = config.Caps{MaxCostUSD: 0.5}
+	if _, _, aerr := s.createTask(ctx, molecular.CreateTaskRequest{TaskID: "cost", Prompt: "p"}, "", nil); aerr != nil {
+		t.Fatal(aerr)
+	}
+	waitFinished(t, s, "cost")
+	retried, aerr := s.retryTask(ctx, "cost", molecular.RetryRequest{Fresh: true})
+	if aerr != nil {
+		t.Fatal(aerr)
+	}
+	waitFinished(t, s, retried.TaskID)
+	s.mu.Lock()
+	got := s.tasks[retried.TaskID].t
+	s.mu.Unlock()
+	if got.Status != statusBudgetExceeded || got.Error.Details["cap"] != "task.max_cost_usd" {
+		t.Fatalf("expected the cost cap: %+v %+v", got, got.Error)
+	}
+
+	// the time cap counts from creation
+	s.limits.Task = config.Caps{MaxDuration: time.Hour}
+	cctx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+	st := &storedTask{t: molecular.Task{TaskID: "slow", Prompt: "p", Status: "running"}, ctx: cctx, cancel: cancel, created: time.Now().Add(-2 * time.Hour)}
+	s.tasks["slow"] = st
+	s.run(st)
+	if st.t.Status != statusBudgetExceeded || st.t.Error.Details["cap"] != "task.max_duration" {
+		t.Fatalf("expected the time cap: %+v %+v", st.t, st.t.Error)
+	}
+}
+
+func TestDailyCaps(t *testing.T) {
+	s := newServer(artifacts.New(t.TempDir()))
+	s.limits.Daily = config.Caps{MaxCostUSD: 0.2}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	create := func(id string) (*http.Response, molecular.Error) {
+		t.Helper()
+		b, _ := json.Marshal(molecular.CreateTaskRequest{TaskID: id, Prompt: "p"})
+		resp, err := http.Post(srv.URL+"/v1/tasks", "application/json", bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("create %s: %v", id, err)
+		}
+		defer resp.Body.Close()
+		var e molecular.Error
+		if resp.StatusCode != http.StatusCreated {
+			_ = json.NewDecoder(resp.Body).Decode(&e)
+		}
+		return resp, e
+	}
+
+	// the first task's attempt reports more than the daily cap
+	results := filepath.Join(s.store.TaskDir("t1"), filepath.FromSlash(artifacts.AttemptRel(1, "carbon")), artifacts.DirResults)
+	if err := os.MkdirAll(results, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(results, task.UsageFile), []byte(`{"input_tokens":10,"output_tokens":5,"cost_usd":0.25}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if resp, e := create("t1"); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("first create: %d %+v", resp.StatusCode, e)
+	}
+	waitFinished(t, s, "t1")
+
+	resp, e := create("t2")
+	if resp.StatusCode != http.StatusTooManyRequests || e.Code != molecular.CodeBudgetExceeded || e.Details["cap"] != "daily.max_cost_usd" {
+		t.Fatalf("expected the daily cap to refuse the submit: %d %+v", resp.StatusCode, e)
+	}
+	if _, ok := s.tasks["t2"]; ok {
+		t.Fatalf("refused task was registered")
+	}
+
+	// the ledger starts over the next day
+	s.mu.Lock()
+	s.daily.day = "2000-01-01"
+	s.mu.Unlock()
+	if resp, e := create("t3"); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create after rollover: %d %+v", resp.StatusCode, e)
+	}
+	waitFinished(t, s, "t3")
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// Sort orders accepted by GET /v1/tasks. A leading "-" means descending.
+var listSorts = map[string]func(t molecular.Task) string{
+	"created_at":  func(t molecular.Task) string { return t.CreatedAt },
+	"-created_at": func(t molecular.Task) string { return t.CreatedAt },
+	"updated_at":  func(t molecular.Task) string { return t.UpdatedAt },
+	"-updated_at": func(t molecular.Task) string { return t.UpdatedAt },
+}
+
+const defaultListSort = "-created_at"
+
+// listQuery is a parsed GET /v1/tasks query.
+type listQuery struct {
+	status, phase, prefix string
+	selector              molecular.Selector
+	since, until          time.Time
+	sort                  string
+	limit                 int
+	after                 *listCursor
+}
+
+// listCursor marks the last task of a page. It is sent to clients as an
+// opaque base64 string.
+type listCursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	ID   string `json:"id"`
+}
+
+func (c listCursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*listCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c listCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	if _, ok := listSorts[c.Sort]; !ok || c.ID == "" {
+		return nil, errors.New("malformed cursor")
+	}
+	return &c, nil
+}
+
+// parseListQuery validates the filter, sort and paging parameters.
+func parseListQuery(r *http.Request) (listQuery, *requestError) {
+	v := r.URL.Query()
+	q := listQuery{
+		status: v.Get("status"),
+		phase:  v.Get("phase"),
+		prefix: v.Get("prefix"),
+		sort:   v.Get("sort"),
+	}
+	if q.sort == "" {
+		q.sort = defaultListSort
+	}
+	if _, ok := listSorts[q.sort]; !ok {
+		return q, &requestError{http.StatusBadRequest, molecular.CodeInvalidRequest, "unknown sort " + strconv.Quote(q.sort)}
+	}
+	for name, dst := range map[string]*time.Time{"since": &q.since, "until": &q.until} {
+		if s := v.Get(name); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return q, &requestError{http.StatusBadRequest, molecular.CodeInvalidRequest, name + " must be an RFC 3339 time"}
+			}
+			*dst = t
+		}
+	}
+	sel, err := molecular.ParseSelector(v.Get("selector"))
+	if err != nil {
+		return q, &requestError{http.StatusBadRequest, molecular.CodeInvalidRequest, "selector: " + err.Error()}
+	}
+	q.selector = sel
+	// label=k=v (repeatable) is the simple form: every label must equal
+	for _, l := range v["label"] {
+		k, val, ok := strings.Cut(l, "=")
+		if !ok || molecular.ValidateLabels(map[string]string{k: val}) != nil {
+			return q, &requestError{http.StatusBadRequest, molecular.CodeInvalidRequest, "label must be key=value, got " + strconv.Quote(l)}
+		}
+		q.selector = append(q.selector, molecular.Requirement{Key: k, Op: "=", Value: val})
+	}
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return q, &requestError{http.StatusBadRequest, molecular.CodeInvalidRequest, "limit must be a positive integer"}
+		}
+		q.limit = n
+	}
+	if s := v.Get("cursor"); s != "" {
+		c, err := decodeCursor(s)
+		if err != nil {
+			return q, &requestError{http.StatusBadRequest, molecular.CodeInvalidRequest, "invalid cursor"}
+		}
+		if c.Sort != q.sort {
+			return q, &requestError{http.StatusBadRequest, molecular.CodeInvalidRequest, "cursor was issued for sort " + c.Sort}
+		}
+		q.after = c
+	}
+	return q, nil
+}
+
+// match reports whether t passes the query's filters.
+func (q listQuery) match(t molecular.Task) bool {
+	if q.status != "" && string(t.Status) != q.status {
+		return false
+	}
+	if q.phase != "" && t.Phase != q.phase {
+		return false
+	}
+	if q.prefix != "" && !strings.HasPrefix(t.TaskID, q.prefix) {
+		return false
+	}
+	if !q.selector.Matches(t.Labels) {
+		return false
+	}
+	if !q.since.IsZero() || !q.until.IsZero() {
+		created, err := time.Parse(time.RFC3339, t.CreatedAt)
+		if err != nil {
+			return false
+		}
+		if !q.since.IsZero() && created.Before(q.since) {
+			return false
+		}
+		if !q.until.IsZero() && !created.Before(q.until) {
+			return false
+		}
+	}
+	return true
+}
+
+// less orders tasks by the query's sort key, breaking ties by task ID so
+// the order is total and cursors are stable.
+func (q listQuery) less(aKey, aID, bKey, bID string) bool {
+	if strings.HasPrefix(q.sort, "-") {
+		aKey, aID, bKey, bID = bKey, bID, aKey, aID
+	}
+	if aKey != bKey {
+		return aKey < bKey
+	}
+	return aID < bID
+}
+
+// handleList serves GET /v1/tasks. Query parameters:
+//
+//	status, phase  exact match
+//	prefix         task ID prefix
+//	selector       label selector, e.g. epic=billing,owner!=bob
+//	since, until   created_at window, RFC 3339 (until is exclusive)
+//	sort           created_at, updated_at; "-" prefix for descending (default -created_at)
+//	limit, cursor  page size and the next_cursor of the previous page
+func (s *server) handleList(w http.ResponseWriter, r *http.Request) {
+	q, rerr := parseListQuery(r)
+	if rerr != nil {
+		writeError(w, r, rerr.status, rerr.code, rerr.msg, nil)
+		return
+	}
+	key := listSorts[q.sort]
+
+	s.mu.Lock()
+	tasks := []molecular.Task{}
+	for _, st := range s.tasks {
+		if q.match(st.t) {
+			tasks = append(tasks, st.t)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return q.less(key(tasks[i]), tasks[i].TaskID, key(tasks[j]), tasks[j].TaskID)
+	})
+	if q.after != nil {
+		i := sort.Search(len(tasks), func(i int) bool {
+			return q.less(q.after.Key, q.after.ID, key(tasks[i]), tasks[i].TaskID)
+		})
+		tasks = tasks[i:]
+	}
+	resp := molecular.TaskList{Tasks: tasks}
+	if q.limit > 0 && len(tasks) > q.limit {
+		resp.Tasks = tasks[:q.limit]
+		last := resp.Tasks[q.limit-1]
+		resp.NextCursor = listCursor{Sort: q.sort, Key: key(last), ID: last.TaskID}.encode()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+func TestList_FilterSortPaginate(t *testing.T) {
+	s := newServer(artifacts.New(t.TempDir()))
+	for _, tk := range []molecular.Task{
+		{TaskID: "fix-a", Status: "completed", Phase: "done", CreatedAt: "2026-01-01T10:00:00Z", UpdatedAt: "2026-01-03T00:00:00Z", Labels: map[string]string{"epic": "billing", "owner": "ana"}},
+		{TaskID: "fix-b", Status: "running", Phase: "executing", CreatedAt: "2026-01-02T10:00:00Z", UpdatedAt: "2026-01-02T10:00:00Z", Labels: map[string]string{"epic": "billing"}},
+		{TaskID: "doc-c", Status: "completed", Phase: "done", CreatedAt: "2026-01-03T10:00:00Z", UpdatedAt: "2026-01-03T10:00:00Z"},
+		{TaskID: "doc-d", Status: "cancelled", Phase: "cancelled", CreatedAt: "2026-01-03T10:00:00Z", UpdatedAt: "2026-01-04T00:00:00Z"},
+	} {
+		s.tasks[tk.TaskID] = &storedTask{t: tk}
+	}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	list := func(query string) (int, molecular.TaskList) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + "/v1/tasks?" + query)
+		if err != nil {
+			t.Fatalf("list %q: %v", query, err)
+		}
+		defer resp.Body.Close()
+		var page molecular.TaskList
+		_ = json.NewDecoder(resp.Body).Decode(&page)
+		return resp.StatusCode, page
+	}
+	ids := func(page molecular.TaskList) string {
+		var out []string
+		for _, tk := range page.Tasks {
+			out = append(out, tk.TaskID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	cases := []struct{ query, want string }{
+		// newest first; equal created_at falls back to task ID
+		{"", "doc-d,doc-c,fix-b,fix-a"},
+		{"sort=created_at", "fix-a,fix-b,doc-c,doc-d"},
+		{"sort=-updated_at", "doc-d,doc-c,fix-a,fix-b"},
+		{"status=completed", "doc-c,fix-a"},
+		{"phase=executing", "fix-b"},
+		{"prefix=doc-", "doc-d,doc-c"},
+		{"since=2026-01-02T10:00:00Z", "doc-d,doc-c,fix-b"},
+		{"until=2026-01-02T10:00:00Z", "fix-a"},
+		{"status=nope", ""},
+		{"selector=epic%3Dbilling", "fix-b,fix-a"},
+		{"selector=epic%3Dbilling,owner!%3Dana", "fix-b"},
+		{"selector=!epic", "doc-d,doc-c"},
+		{"selector=owner", "fix-a"},
+		{"label=epic%3Dbilling", "fix-b,fix-a"},
+		{"label=epic%3Dbilling&label=owner%3Dana", "fix-a"},
+		{"label=epic%3Dbilling&selector=!owner", "fix-b"},
+		{"label=epic%3Ddocs", ""},
+	}
+	for _, c := range cases {
+		code, page := list(c.query)
+		if code != http.StatusOK || ids(page) != c.want || page.NextCursor != "" {
+			t.Fatalf("%q: got %d %q (cursor %q), want %q", c.query, code, ids(page), page.NextCursor, c.want)
+		}
+	}
+
+	// walk the pages
+	var got []string
+	query := "limit=3"
+	for {
+		code, page := list(query)
+		if code != http.StatusOK {
+			t.Fatalf("page %q: %d", query, code)
+		}
+		got = append(got, ids(page))
+		if page.NextCursor == "" {
+			break
+		}
+		query = "limit=3&cursor=" + page.NextCursor
+	}
+	if strings.Join(got, "|") != "doc-d,doc-c,fix-b|fix-a" {
+		t.Fatalf("pages: %v", got)
+	}
+
+	// a cursor survives tasks being added before it
+	_, first := list("limit=2")
+	s.mu.Lock()
+	s.tasks["new-e"] = &storedTask{t: molecular.Task{TaskID: "new-e", CreatedAt: "2026-02-01T00:00:00Z"}}
+	s.mu.Unlock()
+	if _, page := list("limit=2&cursor=" + first.NextCursor); ids(page) != "fix-b,fix-a" {
+		t.Fatalf("after insert: %q", ids(page))
+	}
+
+	for _, bad := range []string{"limit=0", "limit=x", "since=yesterday", "sort=prompt", "selector=a%3D%3D%3D", "label=epic", "label=%3Dx", "cursor=!!", "sort=created_at&cursor=" + first.NextCursor} {
+		if code, _ := list(bad); code != http.StatusBadRequest {
+			t.Fatalf("%q: expected 400, got %d", bad, code)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/throw-if-null/molecular/internal/api"
+)
+
+// Environment variables consulted when the matching flag is not set.
+const (
+	envListen     = "SILICON_LISTEN"
+	envSocketMode = "SILICON_SOCKET_MODE"
+)
+
+// defaultSocketMode restricts a Unix socket to its owner; access control
+// for socket listeners is done through file permissions.
+const defaultSocketMode fs.FileMode = 0o600
+
+// listenConfig resolves the listen address and socket mode from flag
+// values, falling back to the environment and then to defaults.
+func listenConfig(listenFlag, modeFlag string) (string, fs.FileMode, error) {
+	addr := listenFlag
+	if addr == "" {
+		addr = os.Getenv(envListen)
+	}
+	if addr == "" {
+		addr = api.DefaultAddress()
+	}
+
+	mode := defaultSocketMode
+	m := modeFlag
+	if m == "" {
+		m = os.Getenv(envSocketMode)
+	}
+	if m != "" {
+		n, err := strconv.ParseUint(m, 8, 32)
+		if err != nil || n > 0o777 {
+			return "", 0, fmt.Errorf("invalid socket mode %q", m)
+		}
+		mode = fs.FileMode(n)
+	}
+	return addr, mode, nil
+}
+
+// listen opens a listener for addr. Unix sockets get mode applied and a
+// stale socket left behind by a previous run is replaced; a socket that
+// still accepts connections is treated as in use.
+func listen(addr string, mode fs.FileMode) (net.Listener, error) {
+	network, address, err := api.ParseAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+	if network != "unix" {
+		return net.Listen(network, address)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(address), 0o700); err != nil {
+		return nil, err
+	}
+	if fi, err := os.Lstat(address); err == nil {
+		if fi.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", address)
+		}
+		if c, err := net.DialTimeout("unix", address, time.Second); err == nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("%s is in use", address)
+		}
+		if err := os.Remove(address); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	ln, err := net.Listen("unix", address)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(address, mode); err != nil {
+		_ = ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
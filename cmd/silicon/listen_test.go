@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/throw-if-null/molecular/internal/api"
+	"github.com/throw-if-null/molecular/internal/artifacts"
+)
+
+func TestListenConfig(t *testing.T) {
+	t.Setenv(envListen, "")
+	t.Setenv(envSocketMode, "")
+	addr, mode, err := listenConfig("", "")
+	if err != nil || addr != api.DefaultAddress() || mode != defaultSocketMode {
+		t.Fatalf("defaults: %q %o %v", addr, mode, err)
+	}
+
+	t.Setenv(envListen, "unix:///tmp/env.sock")
+	t.Setenv(envSocketMode, "0660")
+	addr, mode, err = listenConfig("", "")
+	if err != nil || addr != "unix:///tmp/env.sock" || mode != 0o660 {
+		t.Fatalf("env: %q %o %v", addr, mode, err)
+	}
+
+	addr, _, err = listenConfig("127.0.0.1:9999", "")
+	if err != nil || addr != "127.0.0.1:9999" {
+		t.Fatalf("flag should win over env: %q %v", addr, err)
+	}
+
+	if _, _, err := listenConfig("", "999"); err == nil {
+		t.Fatalf("expected error for invalid mode")
+	}
+}
+
+func TestListen_UnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "run", "silicon.sock")
+	addr := api.UnixScheme + sock
+
+	ln, err := listen(addr, 0o600)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	fi, err := os.Stat(sock)
+	if err != nil {
+		t.Fatalf("stat socket: %v", err)
+	}
+	if fi.Mode()&fs.ModeSocket == 0 || fi.Mode().Perm() != 0o600 {
+		t.Fatalf("unexpected socket mode: %v", fi.Mode())
+	}
+
+	srv := &http.Server{Handler: newServer(artifacts.New(t.TempDir()))}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	// a live socket must not be stolen
+	if _, err := listen(addr, 0o600); err == nil {
+		t.Fatalf("expected error for socket in use")
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", sock)
+		},
+	}}
+	resp, err := client.Get("http://unix/v1/tasks")
+	if err != nil {
+		t.Fatalf("get over unix socket: %v", err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	}
+}
+
+func TestListen_ReplacesStaleSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "silicon.sock")
+	// leave a socket file behind without anyone listening on it
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: sock, Net: "unix"})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	l.SetUnlinkOnClose(false)
+	l.Close()
+
+	ln, err := listen(api.UnixScheme+sock, 0o600)
+	if err != nil {
+		t.Fatalf("expected stale socket to be replaced: %v", err)
+	}
+	ln.Close()
+
+	if err := os.WriteFile(sock, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listen(api.UnixScheme+sock, 0o600); err == nil {
+		t.Fatalf("expected error for non-socket file")
+	}
+}
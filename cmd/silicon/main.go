@@ -2,13 +2,19 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -17,230 +23,790 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/throw-if-null/molecular/internal/api"
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/internal/auth"
+	"github.com/throw-if-null/molecular/internal/config"
+	"github.com/throw-if-null/molecular/internal/prompt"
+	"github.com/throw-if-null/molecular/internal/state"
 	"github.com/throw-if-null/molecular/internal/task"
 	"github.com/throw-if-null/molecular/internal/telemetry"
 	"github.com/throw-if-null/molecular/internal/version"
+	"github.com/throw-if-null/molecular/pkg/molecular"
 )
 
 // allow tests to override init functions
 var telemetryInit = telemetry.Init
 var dotenvLoad = godotenv.Load
 
+// envAuth enables token authentication when the --auth flag is not set.
+const envAuth = "SILICON_AUTH"
+
+// envConfig names the configuration file when the --config flag is not set.
+const envConfig = "SILICON_CONFIG"
+
+// envAgent is the agent command line when the --agent flag is not set.
+const envAgent = "SILICON_AGENT"
+
+// envTelemetry picks the telemetry exporter mode when the --telemetry flag
+// is not set.
+const envTelemetry = "SILICON_TELEMETRY"
+
+// options carries command-line settings into setup.
+type options struct {
+	// auth requires bearer tokens from the state dir's token file.
+	auth bool
+	// config is the configuration file with the limits; a missing file
+	// means no limits.
+	config string
+	// telemetry is the exporter mode; empty defers to the environment and
+	// then to OTLP.
+	telemetry string
+	// agent is the command line run for each attempt; empty runs none.
+	agent string
+}
+
+// logTelemetry says where spans go, so a laptop without a collector is
+// not left wondering why none show up.
+func logTelemetry(cfg telemetry.Config) {
+	switch cfg.Exporter {
+	case telemetry.ExporterFile:
+		slog.Info("telemetry", "exporter", cfg.Exporter, "file", filepath.Join(cfg.FileDir, telemetry.SpansFile))
+	case telemetry.ExporterStdout, telemetry.ExporterNone:
+		slog.Info("telemetry", "exporter", cfg.Exporter)
+	default:
+		endpoint := cfg.OTLPEndpoint
+		if endpoint == "" {
+			endpoint = "default"
+		}
+		protocol := cfg.Protocol
+		if protocol == "" {
+			protocol = telemetry.ProtocolHTTP
+		}
+		slog.Info("telemetry", "exporter", telemetry.ExporterOTLP, "endpoint", endpoint, "protocol", protocol)
+	}
+}
+
 // setup prepares the HTTP handler and initializes telemetry. It returns the
 // handler to serve, a shutdown function to clean up telemetry, and an error
 // if initialization failed. This is separated out to allow end-to-end tests
 // to call into the server without binding to a fixed port.
-func setup(ctx context.Context) (http.Handler, func(context.Context) error, error) {
+func setup(ctx context.Context, opts options) (http.Handler, func(context.Context) error, error) {
 	if err := dotenvLoad(); err != nil {
 		slog.Warn("loading .env", "err", err)
 	}
+	if !opts.auth {
+		if v := os.Getenv(envAuth); v != "" {
+			enabled, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", envAuth, err)
+			}
+			opts.auth = enabled
+		}
+	}
+
+	stateDir, err := state.Dir()
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// initialize telemetry; fail-fast on error
 	shutdown := func(context.Context) error { return nil }
 	if initer := telemetryInit; initer != nil {
-		var err error
-		shutdown, err = initer(ctx, telemetry.Config{ServiceName: "molecular-silicon", ServiceVersion: version.Version})
+		tcfg, err := telemetry.FromEnv(telemetry.Config{ServiceName: "molecular-silicon", ServiceVersion: version.Version})
 		if err != nil {
 			return nil, nil, err
 		}
+		if opts.telemetry == "" {
+			opts.telemetry = os.Getenv(envTelemetry)
+		}
+		if opts.telemetry != "" {
+			tcfg.Exporter = opts.telemetry
+		}
+		tcfg.FileDir = filepath.Join(stateDir, "spans")
+		shutdown, err = initer(ctx, tcfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("telemetry: %w", err)
+		}
+		logTelemetry(tcfg)
+	}
+
+	if opts.config == "" {
+		opts.config = os.Getenv(envConfig)
+	}
+	if opts.config == "" {
+		opts.config = config.Path
+	}
+	cfg, err := config.Load(opts.config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	store := artifacts.New(filepath.Join(stateDir, "tasks"))
+	if err := os.MkdirAll(store.Root, 0o755); err != nil {
+		return nil, nil, err
+	}
+
+	if opts.agent == "" {
+		opts.agent = os.Getenv(envAgent)
+	}
+	if opts.agent == "" {
+		slog.Info("no agent command; attempts only render their prompts", "env", envAgent)
 	}
 
-	srv := newServer()
-	return srv, shutdown, nil
+	srv := newServer(store)
+	srv.limits = cfg.Limits
+	srv.runner = task.Runner{Command: opts.agent}
+	var handler http.Handler = srv
+	if opts.auth {
+		path := auth.TokensPath(stateDir)
+		slog.Info("token authentication enabled", "tokens", path)
+		handler = withAuth(auth.NewKeyring(path), handler)
+	}
+	return withTracing(srv.withMetrics(withRecovery(handler))), shutdown, nil
 }
 
 type server struct {
-	mu    sync.Mutex
-	tasks map[string]*storedTask
+	mu            sync.Mutex
+	tasks         map[string]*storedTask
+	idempotency   map[string]string // idempotency key -> task id
+	store         *artifacts.Store
+	worktreeRoot  string
+	nextAttemptID int64
+	mux           *http.ServeMux
+	// limits caps usage; daily is what counts toward the daily caps.
+	limits config.Limits
+	daily  ledger
+	// metrics are served on GET /metrics.
+	metrics *serverMetrics
+	// runner runs each attempt's agent.
+	runner task.Runner
 }
 
 type storedTask struct {
-	t       api.Task
+	t       molecular.Task
 	cancel  context.CancelFunc
 	ctx     context.Context
 	created time.Time
 	updated time.Time
+	// phaseSince is when the task entered its current phase.
+	phaseSince time.Time
+	// events is the task's lifecycle stream; wake is closed and replaced
+	// on every append. Both are guarded by server.mu.
+	events []molecular.Event
+	wake   chan struct{}
+	// prior is what the tasks this one retries consumed. It counts toward
+	// the task caps, so retrying does not reset them.
+	prior molecular.Usage
 }
 
-func newServer() *server {
-	return &server{tasks: make(map[string]*storedTask)}
+// newServer returns a server keeping artifacts in store. Task worktrees are
+// created in a "worktrees" directory next to the store's root.
+func newServer(store *artifacts.Store) *server {
+	s := &server{
+		tasks:        make(map[string]*storedTask),
+		idempotency:  make(map[string]string),
+		store:        store,
+		worktreeRoot: filepath.Join(filepath.Dir(store.Root), "worktrees"),
+	}
+	s.mux = newMux(s.routes())
+	s.metrics = newMetrics(s)
+	return s
 }
 
-func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// basic routing
-	if r.URL.Path == "/v1/tasks" {
-		switch r.Method {
-		case http.MethodPost:
-			s.handleCreate(w, r)
-			return
-		case http.MethodGet:
-			s.handleList(w, r)
-			return
-		}
+// maxTaskIDLen bounds task IDs, which double as directory names.
+const maxTaskIDLen = 128
+
+// budgets bounds the attempts each role gets on a task.
+type budgets struct {
+	carbon, helium, review int
+}
+
+var defaultBudgets = budgets{carbon: 3, helium: 3, review: 2}
+
+// validTaskID reports whether id is safe to use as a URL path segment and
+// as a directory name under the artifacts root.
+func validTaskID(id string) bool {
+	if id == "" || len(id) > maxTaskIDLen || id[0] == '.' || id[0] == '-' {
+		return false
 	}
-	if p, ok := strings.CutPrefix(r.URL.Path, "/v1/tasks/"); ok {
-		// p is the path after the prefix
-		// possible forms: {id}, {id}/cancel, {id}/logs, {id}/cleanup
-		parts := strings.SplitN(p, "/", 2)
-		id := parts[0]
-		if len(parts) == 1 || parts[1] == "" {
-			if r.Method == http.MethodGet {
-				s.handleGet(w, r, id)
-				return
-			}
-		} else {
-			switch parts[1] {
-			case "cancel":
-				if r.Method == http.MethodPost {
-					s.handleCancel(w, r, id)
-					return
-				}
-			case "logs":
-				if r.Method == http.MethodGet {
-					s.handleLogs(w, r, id)
-					return
-				}
-			case "cleanup":
-				if r.Method == http.MethodPost {
-					http.Error(w, "not implemented", http.StatusNotFound)
-					return
-				}
-			}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.':
+		default:
+			return false
 		}
 	}
-	http.NotFound(w, r)
+	return true
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
 }
 
 func (s *server) handleCreate(w http.ResponseWriter, r *http.Request) {
-	var req api.CreateTaskRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
+	var req molecular.CreateTaskRequest
+	if !decodeBody(w, r, &req) {
 		return
 	}
-	if req.TaskID == "" {
-		http.Error(w, "task_id required", http.StatusBadRequest)
+	t, existing, aerr := s.createTask(r.Context(), req, r.Header.Get(molecular.IdempotencyKeyHeader), nil)
+	if aerr != nil {
+		writeAPIError(w, r, aerr)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	if !existing {
+		w.Header().Set("Location", "/v1/tasks/"+t.TaskID)
+		w.WriteHeader(http.StatusCreated)
+	}
+	_ = json.NewEncoder(w).Encode(t)
+}
+
+// createTask validates req, registers the task and starts running it.
+// existing reports that key or if_not_exists resolved the request to a
+// task created earlier, which is returned instead. from is set for a retry
+// and carries what it inherits from the task it repeats.
+func (s *server) createTask(ctx context.Context, req molecular.CreateTaskRequest, key string, from *retrySpec) (t molecular.Task, existing bool, aerr *molecular.Error) {
+	if req.TaskID != "" && !validTaskID(req.TaskID) {
+		return t, false, apiError(http.StatusBadRequest, molecular.CodeInvalidRequest, "invalid task_id", map[string]any{"task_id": req.TaskID})
+	}
+	if err := molecular.ValidateLabels(req.Labels); err != nil {
+		return t, false, apiError(http.StatusBadRequest, molecular.CodeInvalidRequest, err.Error(), nil)
+	}
+	if err := molecular.ValidateMetadata(req.Metadata); err != nil {
+		return t, false, apiError(http.StatusBadRequest, molecular.CodeInvalidRequest, err.Error(), nil)
+	}
+
+	// retried submits return the task created by the first attempt
+	s.mu.Lock()
+	if st := s.existingFor(req, key); st != nil {
+		resp := st.t
+		s.mu.Unlock()
+		return resp, true, nil
+	}
+	if aerr := s.dailyCapLocked(time.Now()); aerr != nil {
+		s.mu.Unlock()
+		s.countBudgetExceeded(aerr)
+		return t, false, aerr
+	}
+	if req.TaskID == "" {
+		req.TaskID = newTaskID(req.Prompt)
+		for s.tasks[req.TaskID] != nil {
+			req.TaskID = newTaskID(req.Prompt)
+		}
+	}
+	s.mu.Unlock()
+	if req.PromptTemplate != nil && req.PromptTemplate.Name == "" {
+		return t, false, apiError(http.StatusBadRequest, molecular.CodeInvalidRequest, "prompt_template.name required", nil)
+	}
+	budgets := defaultBudgets
+	var spec *worktreeSpec
+	var err error
+	if from != nil {
+		budgets, spec = from.budgets, from.worktree
+	}
+	if spec == nil {
+		spec, err = resolveWorktree(ctx, req)
+	}
+	if err != nil {
+		var rerr *requestError
+		if errors.As(err, &rerr) {
+			return t, false, apiError(rerr.status, rerr.code, rerr.msg, nil)
+		}
+		return t, false, apiError(http.StatusInternalServerError, molecular.CodeInternal, err.Error(), nil)
+	}
 
 	now := time.Now().UTC()
-	t := api.Task{
+	t = molecular.Task{
 		TaskID:       req.TaskID,
 		Prompt:       req.Prompt,
 		Status:       "running",
 		Phase:        "pending",
 		CreatedAt:    now.Format(time.RFC3339),
 		UpdatedAt:    now.Format(time.RFC3339),
-		CarbonBudget: 3,
-		HeliumBudget: 3,
-		ReviewBudget: 2,
+		CarbonBudget: budgets.carbon,
+		HeliumBudget: budgets.helium,
+		ReviewBudget: budgets.review,
+
+		PromptTemplate: req.PromptTemplate,
+		Labels:         req.Labels,
+		Metadata:       req.Metadata,
+	}
+	if from != nil {
+		t.RetryOf = from.of
+		t.Feedback = from.feedback
+	}
+	if spec != nil {
+		t.RepoPath = spec.repo
+		t.BaseRef = spec.baseRef
+		t.BaseCommit = spec.commit
+		t.BranchName = spec.branch
 	}
 
 	// per-task context with cancel
-	ctx, cancel := context.WithCancel(context.Background())
+	taskCtx, cancel := context.WithCancel(context.Background())
 
-	st := &storedTask{t: t, cancel: cancel, ctx: ctx, created: now, updated: now}
+	st := &storedTask{t: t, cancel: cancel, ctx: taskCtx, created: now, updated: now, phaseSince: now}
+	if from != nil {
+		st.prior = from.prior
+	}
 
 	s.mu.Lock()
+	if prev := s.existingFor(req, key); prev != nil {
+		resp := prev.t
+		s.mu.Unlock()
+		cancel()
+		return resp, true, nil
+	}
 	if _, exists := s.tasks[req.TaskID]; exists {
 		s.mu.Unlock()
-		http.Error(w, "task exists", http.StatusConflict)
-		return
+		cancel()
+		return t, false, apiError(http.StatusConflict, molecular.CodeTaskExists, "task exists", map[string]any{"task_id": req.TaskID})
 	}
 	s.tasks[req.TaskID] = st
+	if key != "" {
+		s.idempotency[key] = req.TaskID
+	}
 	s.mu.Unlock()
 
-	// kick off execution in goroutine
-	go func(st *storedTask) {
-		// update phase/status
+	root, err := s.store.CreateTask(req.TaskID)
+	if err != nil {
 		s.mu.Lock()
-		st.t.Phase = "executing"
-		st.t.Status = "running"
-		st.t.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+		delete(s.tasks, req.TaskID)
 		s.mu.Unlock()
+		cancel()
+		return t, false, apiError(http.StatusInternalServerError, molecular.CodeInternal, "creating artifacts", nil)
+	}
+	var worktree string
+	if spec != nil && spec.path != "" {
+		worktree = spec.path
+	} else if spec != nil {
+		if worktree, err = s.createWorktree(ctx, req.TaskID, spec); err != nil {
+			s.mu.Lock()
+			delete(s.tasks, req.TaskID)
+			s.mu.Unlock()
+			cancel()
+			return t, false, apiError(http.StatusInternalServerError, molecular.CodeInternal, err.Error(), nil)
+		}
+	}
+	s.mu.Lock()
+	st.t.ArtifactsRoot = root
+	st.t.WorktreePath = worktree
+	s.emitLocked(st, molecular.EventTaskCreated)
+	resp := st.t
+	s.mu.Unlock()
+	s.logf(req.TaskID, "task created")
+
+	// kick off execution in goroutine
+	go s.run(st)
+	return resp, false, nil
+}
+
+// existingFor returns the task a create request should resolve to instead
+// of creating a new one: the task recorded for its idempotency key, or the
+// task with its ID when if_not_exists is set. Callers must hold s.mu.
+func (s *server) existingFor(req molecular.CreateTaskRequest, key string) *storedTask {
+	if key != "" {
+		if id, ok := s.idempotency[key]; ok {
+			if st, ok := s.tasks[id]; ok {
+				return st
+			}
+		}
+	}
+	if req.IfNotExists && req.TaskID != "" {
+		return s.tasks[req.TaskID]
+	}
+	return nil
+}
 
-		_ = task.Execute(st.ctx, st.t)
+// run drives a task through its lifecycle, recording each attempt under
+// the task's artifacts root.
+func (s *server) run(st *storedTask) {
+	id := st.t.TaskID
 
+	// update phase/status
+	s.mu.Lock()
+	s.setPhaseLocked(st, "executing", time.Now())
+	st.t.Status = "running"
+	st.t.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	// caps stop a task before an attempt, never in the middle of one,
+	// except for the task's own time cap
+	stop := s.budgetLocked(st, time.Now())
+	s.mu.Unlock()
+
+	var a *molecular.Attempt
+	var usage *molecular.Usage
+	var execErr error
+	var ran time.Duration
+	// fail is why the task failed without running its attempt
+	var fail *molecular.Error
+	if stop == nil {
+		var err error
+		if a, err = s.startAttempt(st, "carbon"); err != nil {
+			fail = attemptError(err)
+		}
+	}
+	if stop == nil && fail == nil {
+		ctx := st.ctx
+		if d := s.limits.Task.MaxDuration; d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(st.ctx, st.created.Add(d))
+			defer cancel()
+		}
 		s.mu.Lock()
-		// if context was cancelled, mark cancelled, else completed
-		select {
-		case <-st.ctx.Done():
-			st.t.Status = "cancelled"
-			st.t.Phase = "cancelled"
-		default:
+		snap := st.t
+		s.mu.Unlock()
+		started := time.Now()
+		usage, execErr = task.Execute(ctx, snap, s.runner)
+		ran = time.Since(started)
+		switch {
+		case errors.Is(execErr, context.DeadlineExceeded):
+			s.mu.Lock()
+			stop = capReached("task", s.limits.Task, 0, 0, time.Since(st.created))
+			s.mu.Unlock()
+		case execErr != nil && ctx.Err() == nil:
+			fail = apiError(http.StatusInternalServerError, molecular.CodeAgentFailed, execErr.Error(), nil)
+		}
+	}
+
+	s.mu.Lock()
+	s.dailyLocked(time.Now()).add(usage, ran)
+	// if context was cancelled, mark cancelled, else completed
+	select {
+	case <-st.ctx.Done():
+		st.t.Status = "cancelled"
+		s.setPhaseLocked(st, "cancelled", time.Now())
+	default:
+		if stop != nil {
+			st.t.Status = statusBudgetExceeded
+			st.t.Error = stop
+			s.countBudgetExceeded(stop)
+		} else if fail != nil {
+			st.t.Status = statusFailed
+			st.t.Error = fail
+		} else {
 			st.t.Status = "completed"
-			st.t.Phase = "done"
 		}
-		st.t.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
-		s.mu.Unlock()
-	}(st)
+		s.setPhaseLocked(st, "done", time.Now())
+	}
+	var attempts int64
+	if st.t.LatestAttempt != nil {
+		attempts = st.t.LatestAttempt.AttemptNum
+	}
+	s.observeAttempts(attempts)
+	now := time.Now().UTC().Format(time.RFC3339)
+	st.t.UpdatedAt = now
+	var snapshot *molecular.Attempt
+	if a != nil {
+		// copy on write: the attempt.started event and task snapshots
+		// handed out earlier share the started attempt
+		done := *a
+		done.Status = string(st.t.Status)
+		done.FinishedAt = now
+		if execErr != nil {
+			done.ErrorSummary = execErr.Error()
+		}
+		if usage != nil {
+			done.Usage = usage
+			addUsageLocked(st, *usage)
+		}
+		st.t.LatestAttempt = &done
+		snapshot = &done
+	}
+	status, taskErr := st.t.Status, st.t.Error
+	s.mu.Unlock()
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(st.t)
+	if snapshot != nil {
+		if b, err := json.MarshalIndent(snapshot, "", "  "); err == nil {
+			rel := artifacts.AttemptRel(snapshot.AttemptNum, snapshot.Role) + "/" + artifacts.DirResults + "/attempt.json"
+			_ = s.store.Write(id, rel, append(b, '\n'))
+		}
+	}
+	if taskErr != nil && (status == statusBudgetExceeded || status == statusFailed) {
+		s.logf(id, "task %s: %s", status, taskErr.Message)
+	} else {
+		s.logf(id, "task %s", status)
+	}
+
+	// emitted last so streams see the attempt results and final log line
+	s.mu.Lock()
+	if snapshot != nil {
+		s.emitLocked(st, molecular.EventAttemptFinished)
+	}
+	switch status {
+	case "cancelled":
+		s.emitLocked(st, molecular.EventTaskCancelled)
+	case statusBudgetExceeded, statusFailed:
+		s.emitLocked(st, molecular.EventTaskFailed)
+	default:
+		s.emitLocked(st, molecular.EventTaskCompleted)
+	}
+	s.mu.Unlock()
 }
 
-func (s *server) handleList(w http.ResponseWriter, r *http.Request) {
-	// best-effort limit
-	var limit int
-	if v := r.URL.Query().Get("limit"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n > 0 {
-			limit = n
-		}
+// statusFailed is the status of a task whose attempt could not start or
+// whose agent failed.
+const statusFailed = "failed"
+
+// promptError is a prompt that could not be rendered: an invalid template,
+// or a prompt over the size limit even after truncation.
+type promptError struct{ err error }
+
+func (e *promptError) Error() string { return "rendering prompt: " + e.err.Error() }
+func (e *promptError) Unwrap() error { return e.err }
+
+// attemptError describes why an attempt could not start: its prompt did
+// not render, or its artifacts could not be written.
+func attemptError(err error) *molecular.Error {
+	var perr *promptError
+	if errors.As(err, &perr) {
+		return apiError(http.StatusUnprocessableEntity, molecular.CodePromptFailed, err.Error(), nil)
 	}
+	return apiError(http.StatusInternalServerError, molecular.CodeInternal, "starting attempt: "+err.Error(), nil)
+}
+
+// addUsageLocked adds an attempt's usage to st's totals. Callers must hold
+// s.mu.
+func addUsageLocked(st *storedTask, u molecular.Usage) {
+	total := molecular.Usage{}
+	if st.t.Usage != nil {
+		total = *st.t.Usage
+	}
+	total.Add(u)
+	st.t.Usage = &total
+
+	// copy on write: task snapshots handed out earlier share the slice
+	byModel := slices.Clone(st.t.UsageByModel)
+	i, found := slices.BinarySearchFunc(byModel, u.Model, func(m molecular.Usage, model string) int {
+		return strings.Compare(m.Model, model)
+	})
+	if !found {
+		byModel = slices.Insert(byModel, i, molecular.Usage{Model: u.Model})
+	}
+	byModel[i].Add(u)
+	st.t.UsageByModel = byModel
+}
+
+// startAttempt allocates the next attempt for st, creates its artifacts
+// directory and records the prompt rendered for the agent. A Carbon
+// attempt's prompt includes the feedback added since the previous one.
+func (s *server) startAttempt(st *storedTask, role string) (*molecular.Attempt, error) {
 	s.mu.Lock()
-	var out []api.Task
-	for _, st := range s.tasks {
-		out = append(out, st.t)
-		if limit > 0 && len(out) >= limit {
-			break
-		}
+	s.nextAttemptID++
+	a := &molecular.Attempt{
+		ID:        s.nextAttemptID,
+		TaskID:    st.t.TaskID,
+		Role:      role,
+		Status:    "running",
+		StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if st.t.LatestAttempt != nil {
+		a.AttemptNum = st.t.LatestAttempt.AttemptNum + 1
+	} else {
+		a.AttemptNum = 1
+	}
+	in := prompt.Input{TaskID: st.t.TaskID, Attempt: a.AttemptNum, Prompt: st.t.Prompt}
+	if st.t.RepoPath != "" {
+		in.Repo = &prompt.Repo{Path: st.t.RepoPath, BaseRef: st.t.BaseRef, BaseCommit: st.t.BaseCommit, Branch: st.t.BranchName}
+	}
+	// feedback steers Carbon, the role that writes the code
+	if role == prompt.RoleCarbon {
+		a.Feedback = pendingFeedbackLocked(st, a.AttemptNum)
+		in.Feedback = a.Feedback
 	}
 	s.mu.Unlock()
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(out)
+
+	rendered, err := renderPrompt(role, in)
+	if err != nil {
+		return nil, &promptError{err}
+	}
+	dir, err := s.store.CreateAttempt(a.TaskID, a.AttemptNum, role)
+	if err != nil {
+		return nil, err
+	}
+	a.ArtifactsDir = dir
+	// the rendered text, and the template and input that produced it
+	base := artifacts.AttemptRel(a.AttemptNum, role) + "/" + artifacts.DirPrompts + "/" + role
+	if err := s.store.Write(a.TaskID, base+".md", []byte(rendered.Text)); err != nil {
+		return nil, err
+	}
+	record, err := json.MarshalIndent(rendered, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Write(a.TaskID, base+".json", append(record, '\n')); err != nil {
+		return nil, err
+	}
+	for _, step := range rendered.Truncated {
+		s.logf(a.TaskID, "attempt %d (%s) prompt truncated: %s", a.AttemptNum, role, step)
+	}
+
+	s.mu.Lock()
+	markFeedbackLocked(st, a.AttemptNum, len(a.Feedback))
+	st.t.LatestAttempt = a
+	st.t.CurrentAttemptID = &a.ID
+	s.emitLocked(st, molecular.EventAttemptStarted)
+	s.mu.Unlock()
+	s.logf(a.TaskID, "attempt %d (%s) started", a.AttemptNum, role)
+	return a, nil
+}
+
+// renderPrompt renders the prompt for role, using the task repository's
+// template override when it has one.
+func renderPrompt(role string, in prompt.Input) (*prompt.Rendered, error) {
+	var repo string
+	if in.Repo != nil {
+		repo = in.Repo.Path
+	}
+	tmpl, err := prompt.Load(role, repo)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.Render(in, 0)
+}
+
+// logf appends a timestamped line to the task's Silicon log. Failures are
+// ignored: the log is a convenience, not a source of truth.
+func (s *server) logf(taskID, format string, args ...any) {
+	line := fmt.Sprintf("%s %s\n", time.Now().UTC().Format(time.RFC3339), fmt.Sprintf(format, args...))
+	_ = s.store.Append(taskID, artifacts.TaskLog, []byte(line))
 }
 
 func (s *server) handleGet(w http.ResponseWriter, r *http.Request, id string) {
 	s.mu.Lock()
 	st, ok := s.tasks[id]
+	var t molecular.Task
+	if ok {
+		// copied under the lock: run and the event code update st.t
+		t = st.t
+	}
 	s.mu.Unlock()
 	if !ok {
-		http.NotFound(w, r)
+		taskNotFound(w, r, id)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(st.t)
+	_ = json.NewEncoder(w).Encode(t)
 }
 
 func (s *server) handleCancel(w http.ResponseWriter, r *http.Request, id string) {
+	t, aerr := s.cancelTask(id)
+	if aerr != nil {
+		writeAPIError(w, r, aerr)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(t)
+}
+
+// cancelTask stops a running task and marks it cancelled.
+func (s *server) cancelTask(id string) (molecular.Task, *molecular.Error) {
 	s.mu.Lock()
+	defer s.mu.Unlock()
 	st, ok := s.tasks[id]
-	s.mu.Unlock()
 	if !ok {
-		http.NotFound(w, r)
-		return
+		return molecular.Task{}, errTaskNotFound(id)
+	}
+	if st.t.Status != "running" {
+		return molecular.Task{}, apiError(http.StatusConflict, molecular.CodeTaskNotRunning, "task is not running", map[string]any{"task_id": id, "status": st.t.Status})
 	}
 	st.cancel()
 	// mark cancelled immediately
-	s.mu.Lock()
 	st.t.Status = "cancelled"
-	st.t.Phase = "cancelled"
+	s.setPhaseLocked(st, "cancelled", time.Now())
 	st.t.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
-	s.mu.Unlock()
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(st.t)
+	return st.t, nil
 }
 
 func (s *server) handleLogs(w http.ResponseWriter, r *http.Request, id string) {
-	// placeholder: return 404 if task not found, else empty body
 	s.mu.Lock()
-	_, ok := s.tasks[id]
+	st, ok := s.tasks[id]
 	s.mu.Unlock()
 	if !ok {
-		http.NotFound(w, r)
+		taskNotFound(w, r, id)
 		return
 	}
+	var tail int
+	if v := r.URL.Query().Get("tail"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			tail = n
+		}
+	}
+	f, _, err := s.store.Open(id, artifacts.TaskLog)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, molecular.CodeInternal, "reading logs", nil)
+		return
+	}
+	defer f.Close()
+	streamResponse(w)
+	b, err := io.ReadAll(f)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, molecular.CodeInternal, "reading logs", nil)
+		return
+	}
+	if tail > 0 {
+		b = tailLines(b, tail)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write([]byte(""))
+	_, _ = w.Write(b)
+	if r.URL.Query().Get("follow") == "true" {
+		s.followLog(w, r, st, f)
+	}
+}
+
+// logPollInterval is how often a followed log is checked for new lines
+// between task events.
+const logPollInterval = 250 * time.Millisecond
+
+// followLog copies whatever is appended to f until the task's terminal
+// event or the client goes away.
+func (s *server) followLog(w http.ResponseWriter, r *http.Request, st *storedTask, f io.Reader) {
+	rc := http.NewResponseController(w)
+	ticker := time.NewTicker(logPollInterval)
+	defer ticker.Stop()
+	for {
+		_ = rc.Flush()
+		s.mu.Lock()
+		_, done, wake := s.waitLocked(st, int64(len(st.events)))
+		s.mu.Unlock()
+		if done {
+			_, _ = io.Copy(w, f)
+			_ = rc.Flush()
+			return
+		}
+		select {
+		case <-wake:
+		case <-ticker.C:
+		case <-r.Context().Done():
+			return
+		}
+		if _, err := io.Copy(w, f); err != nil {
+			return
+		}
+	}
+}
+
+// tailLines returns the last n newline-terminated lines of b.
+func tailLines(b []byte, n int) []byte {
+	end := len(b)
+	if end > 0 && b[end-1] == '\n' {
+		end--
+	}
+	for i := end - 1; i >= 0; i-- {
+		if b[i] == '\n' {
+			n--
+			if n == 0 {
+				return b[i+1:]
+			}
+		}
+	}
+	return b
 }
 
 func main() {
@@ -248,24 +814,54 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	listenFlag := flag.String("listen", "", "listen address: host:port or unix:///path/silicon.sock (env "+envListen+", default "+api.DefaultAddress()+")")
+	modeFlag := flag.String("socket-mode", "", "octal file mode for a unix socket (env "+envSocketMode+", default 0600)")
+	var opts options
+	flag.BoolVar(&opts.auth, "auth", false, "require bearer tokens created with 'molecular auth token create' (env "+envAuth+")")
+	flag.StringVar(&opts.telemetry, "telemetry", "", "telemetry exporter: otlp, stdout, file or none (env "+envTelemetry+", default otlp)")
+	flag.StringVar(&opts.agent, "agent", "", "shell command that runs an attempt's agent, with the prompt on stdin (env "+envAgent+")")
+	flag.StringVar(&opts.config, "config", "", "configuration file with token, cost and time limits (env "+envConfig+", default "+config.Path+")")
+	var tlsOpts tlsOptions
+	flag.StringVar(&tlsOpts.cert, "tls-cert", "", "serve HTTPS with this PEM certificate (env "+envTLSCert+")")
+	flag.StringVar(&tlsOpts.key, "tls-key", "", "PEM private key for --tls-cert (env "+envTLSKey+")")
+	flag.StringVar(&tlsOpts.clientCA, "tls-client-ca", "", "require client certificates signed by this PEM CA (mTLS) (env "+envTLSClientCA+")")
+	flag.Parse()
+
 	// perform setup; fail fast on telemetry init errors
-	handler, shutdown, err := setup(ctx)
+	handler, shutdown, err := setup(ctx, opts)
 	if err != nil {
-		slog.Error("telemetry init", "err", err)
+		slog.Error("setup", "err", err)
 		os.Exit(1)
 	}
 
-	// use default listen addr
-	addr := fmt.Sprintf("%s:%d", api.DefaultHost, api.DefaultPort)
-	slog.Info("starting", "addr", addr)
+	// resolve after setup so values from .env apply
+	addr, mode, err := listenConfig(*listenFlag, *modeFlag)
+	if err != nil {
+		slog.Error("listen config", "err", err)
+		os.Exit(1)
+	}
+	tlsCfg, err := serverTLSConfig(tlsOpts.withEnv())
+	if err != nil {
+		slog.Error("tls config", "err", err)
+		os.Exit(1)
+	}
+	ln, err := listen(addr, mode)
+	if err != nil {
+		slog.Error("listen", "addr", addr, "err", err)
+		os.Exit(1)
+	}
+	if tlsCfg != nil {
+		ln = tls.NewListener(ln, tlsCfg)
+	}
+	slog.Info("starting", "addr", addr, "tls", tlsCfg != nil, "mtls", tlsCfg != nil && tlsCfg.ClientCAs != nil)
 
-	srv := &http.Server{Addr: addr, Handler: handler}
+	srv := newHTTPServer(handler)
 
 	// start server
 	errCh := make(chan error, 1)
 	go func() {
-		// ListenAndServe returns http.ErrServerClosed on graceful shutdown.
-		errCh <- srv.ListenAndServe()
+		// Serve returns http.ErrServerClosed on graceful shutdown.
+		errCh <- srv.Serve(ln)
 	}()
 
 	// wait for termination signal or server error
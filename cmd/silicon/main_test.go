@@ -6,11 +6,16 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
-	"github.com/throw-if-null/molecular/internal/api"
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/internal/state"
 	"github.com/throw-if-null/molecular/internal/telemetry"
+	"github.com/throw-if-null/molecular/pkg/molecular"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	sdkresource "go.opentelemetry.io/otel/sdk/resource"
@@ -19,6 +24,8 @@ import (
 )
 
 func TestEndToEnd_EmitsTaskSpan(t *testing.T) {
+	t.Setenv(state.EnvDir, t.TempDir())
+
 	// override dotenvLoad to no-op
 	oldDot := dotenvLoad
 	dotenvLoad = func(...string) error { return nil }
@@ -49,7 +56,7 @@ func TestEndToEnd_EmitsTaskSpan(t *testing.T) {
 		otel.SetTracerProvider(prev)
 	}()
 
-	handler, shutdown, err := setup(context.Background())
+	handler, shutdown, err := setup(context.Background(), options{})
 	if err != nil {
 		t.Fatalf("setup: %v", err)
 	}
@@ -59,7 +66,7 @@ func TestEndToEnd_EmitsTaskSpan(t *testing.T) {
 	defer srv.Close()
 
 	// create task
-	body := api.CreateTaskRequest{TaskID: "task-1", Prompt: "hello"}
+	body := molecular.CreateTaskRequest{TaskID: "task-1", Prompt: "hello"}
 	b, _ := json.Marshal(body)
 	resp, err := http.Post(srv.URL+"/v1/tasks", "application/json", bytes.NewReader(b))
 	if err != nil {
@@ -77,7 +84,7 @@ func TestEndToEnd_EmitsTaskSpan(t *testing.T) {
 		if err != nil {
 			t.Fatalf("get task: %v", err)
 		}
-		var got api.Task
+		var got molecular.Task
 		_ = json.NewDecoder(resp.Body).Decode(&got)
 		resp.Body.Close()
 		if got.Status == "completed" || got.Status == "cancelled" {
@@ -106,3 +113,101 @@ func TestEndToEnd_EmitsTaskSpan(t *testing.T) {
 		t.Fatalf("did not find silicon.task span with task.id")
 	}
 }
+
+func TestCreate_StoresPromptTemplate(t *testing.T) {
+	srv := httptest.NewServer(newServer(artifacts.New(t.TempDir())))
+	defer srv.Close()
+
+	req := molecular.CreateTaskRequest{
+		TaskID: "task-1",
+		Prompt: "Fix NPE.",
+		PromptTemplate: &molecular.PromptTemplate{
+			Name:   "bugfix",
+			Vars:   map[string]string{"bug": "NPE"},
+			SHA256: "abc",
+			Source: "Fix {{.Vars.bug}}.",
+		},
+	}
+	b, _ := json.Marshal(req)
+	resp, err := http.Post(srv.URL+"/v1/tasks", "application/json", bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("post create: %v", err)
+	}
+	resp.Body.Close()
+
+	got := waitTerminal(t, srv.URL, "task-1")
+	if got.PromptTemplate == nil || got.PromptTemplate.Name != "bugfix" || got.PromptTemplate.Source != req.PromptTemplate.Source {
+		t.Fatalf("expected template metadata on task, got %+v", got.PromptTemplate)
+	}
+
+	req.TaskID = "task-2"
+	req.PromptTemplate.Name = ""
+	b, _ = json.Marshal(req)
+	resp, err = http.Post(srv.URL+"/v1/tasks", "application/json", bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("post create: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unnamed template, got %d", resp.StatusCode)
+	}
+}
+
+func TestSetup_TelemetryModes(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	oldInit, oldDot := telemetryInit, dotenvLoad
+	telemetryInit, dotenvLoad = telemetry.Init, func(...string) error { return nil }
+	defer func() {
+		telemetryInit, dotenvLoad = oldInit, oldDot
+		otel.SetTracerProvider(prev)
+	}()
+	t.Setenv("OTEL_TRACES_EXPORTER", "")
+
+	tests := []struct {
+		name    string
+		flag    string
+		env     map[string]string
+		wantErr string
+	}{
+		{name: "flag file", flag: "file"},
+		{name: "env none", env: map[string]string{envTelemetry: "none"}},
+		{name: "flag wins over env", flag: "file", env: map[string]string{envTelemetry: "bogus"}},
+		{name: "OTEL_TRACES_EXPORTER none", env: map[string]string{"OTEL_TRACES_EXPORTER": "none"}},
+		{name: "unknown mode", flag: "jaeger", wantErr: "unsupported exporter"},
+		{name: "unknown OTEL_TRACES_EXPORTER", env: map[string]string{"OTEL_TRACES_EXPORTER": "zipkin"}, wantErr: "OTEL_TRACES_EXPORTER"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv(state.EnvDir, dir)
+			t.Setenv(envTelemetry, "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			handler, shutdown, err := setup(context.Background(), options{telemetry: tt.flag})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("setup: %v", err)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/tasks", nil))
+			if err := shutdown(context.Background()); err != nil {
+				t.Fatalf("shutdown: %v", err)
+			}
+
+			b, err := os.ReadFile(filepath.Join(dir, "spans", telemetry.SpansFile))
+			if tt.flag == "file" {
+				if err != nil || !strings.Contains(string(b), `"Name":"silicon.http.request"`) {
+					t.Fatalf("expected the request span in the span file, got %q (%v)", b, err)
+				}
+			} else if err == nil {
+				t.Fatalf("expected no span file, got %q", b)
+			}
+		})
+	}
+}
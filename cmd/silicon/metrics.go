@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/throw-if-null/molecular/internal/metrics"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/unit"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// allow tests to collect OTel metrics with an in-memory reader
+var meterProvider = global.MeterProvider
+
+// serverMetrics are the metrics Silicon serves on GET /metrics and, when
+// telemetry is on, exports over OTLP. OTel instruments follow the semantic
+// conventions where they define one, as for HTTP servers.
+type serverMetrics struct {
+	reg            *metrics.Registry
+	phaseDuration  *metrics.Histogram // phase
+	attempts       *metrics.Histogram
+	budgetExceeded *metrics.Counter   // cap
+	httpDuration   *metrics.Histogram // method, route, code
+
+	otelPhaseDuration  instrument.Float64Histogram
+	otelAttempts       instrument.Int64Histogram
+	otelBudgetExceeded instrument.Int64Counter
+	otelHTTPDuration   instrument.Float64Histogram
+	otelHTTPActive     instrument.Int64UpDownCounter
+}
+
+// newMetrics registers s's metrics. Task counts are taken from s.tasks on
+// every scrape or collection.
+func newMetrics(s *server) *serverMetrics {
+	reg := metrics.NewRegistry()
+	reg.GaugeFunc("silicon_tasks", "Tasks by status.", []string{"status"}, func() []metrics.Sample {
+		counts, _ := s.taskCounts()
+		samples := make([]metrics.Sample, 0, len(counts))
+		for status, n := range counts {
+			samples = append(samples, metrics.Sample{LabelValues: []string{string(status)}, Value: float64(n)})
+		}
+		return samples
+	})
+	reg.GaugeFunc("silicon_queue_depth", "Tasks created but not yet executing.", nil, func() []metrics.Sample {
+		_, pending := s.taskCounts()
+		return []metrics.Sample{{Value: float64(pending)}}
+	})
+	m := &serverMetrics{
+		reg: reg,
+		phaseDuration: reg.Histogram("silicon_phase_duration_seconds", "Time tasks spent in a phase before leaving it.",
+			[]float64{1, 5, 15, 30, 60, 300, 900, 1800, 3600, 7200, 14400}, "phase"),
+		attempts: reg.Histogram("silicon_attempts_per_task", "Attempts a task made before finishing.",
+			[]float64{0, 1, 2, 3, 5, 8}),
+		budgetExceeded: reg.Counter("silicon_budget_exceeded_total", "Tasks stopped or submits refused by a limits cap.", "cap"),
+		httpDuration: reg.Histogram("silicon_http_request_duration_seconds", "HTTP request latency by route pattern.",
+			metrics.DefBuckets, "method", "route", "code"),
+	}
+
+	meter := meterProvider().Meter("silicon")
+	var errs [7]error
+	m.otelPhaseDuration, errs[0] = meter.Float64Histogram("silicon.task.phase.duration",
+		instrument.WithDescription("Time tasks spent in a phase before leaving it."), instrument.WithUnit("s"))
+	m.otelAttempts, errs[1] = meter.Int64Histogram("silicon.task.attempts",
+		instrument.WithDescription("Attempts a task made before finishing."), instrument.WithUnit("{attempt}"))
+	m.otelBudgetExceeded, errs[2] = meter.Int64Counter("silicon.limits.exceeded",
+		instrument.WithDescription("Tasks stopped or submits refused by a limits cap."), instrument.WithUnit("{cap}"))
+	m.otelHTTPDuration, errs[3] = meter.Float64Histogram("http.server.duration",
+		instrument.WithDescription("Duration of inbound HTTP requests."), instrument.WithUnit(unit.Milliseconds))
+	m.otelHTTPActive, errs[4] = meter.Int64UpDownCounter("http.server.active_requests",
+		instrument.WithDescription("Number of inbound HTTP requests in flight."), instrument.WithUnit("{request}"))
+	_, errs[5] = meter.Int64ObservableUpDownCounter("silicon.tasks",
+		instrument.WithDescription("Tasks by status."), instrument.WithUnit("{task}"),
+		instrument.WithInt64Callback(func(_ context.Context, o instrument.Int64Observer) error {
+			counts, _ := s.taskCounts()
+			for status, n := range counts {
+				o.Observe(int64(n), attribute.String("silicon.task.status", string(status)))
+			}
+			return nil
+		}))
+	_, errs[6] = meter.Int64ObservableUpDownCounter("silicon.tasks.pending",
+		instrument.WithDescription("Tasks created but not yet executing."), instrument.WithUnit("{task}"),
+		instrument.WithInt64Callback(func(_ context.Context, o instrument.Int64Observer) error {
+			_, pending := s.taskCounts()
+			o.Observe(int64(pending))
+			return nil
+		}))
+	if err := errors.Join(errs[:]...); err != nil {
+		otel.Handle(err)
+	}
+	return m
+}
+
+// taskCounts counts tasks by status, and the tasks still pending.
+func (s *server) taskCounts() (map[molecular.TaskStatus]int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := map[molecular.TaskStatus]int{}
+	var pending int
+	for _, st := range s.tasks {
+		counts[st.t.Status]++
+		if st.t.Phase == "pending" {
+			pending++
+		}
+	}
+	return counts, pending
+}
+
+func (s *server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.metrics.reg.Handler().ServeHTTP(w, r)
+}
+
+// setPhaseLocked moves st to phase, recording how long it spent in the one
+// it leaves. Callers must hold s.mu.
+func (s *server) setPhaseLocked(st *storedTask, phase string, now time.Time) {
+	if st.t.Phase == phase {
+		return
+	}
+	if !st.phaseSince.IsZero() {
+		d := now.Sub(st.phaseSince).Seconds()
+		s.metrics.phaseDuration.Observe(d, st.t.Phase)
+		s.metrics.otelPhaseDuration.Record(context.Background(), d, attribute.String("silicon.task.phase", st.t.Phase))
+	}
+	st.t.Phase = phase
+	st.phaseSince = now
+}
+
+// observeAttempts records how many attempts a finished task made.
+func (s *server) observeAttempts(n int64) {
+	s.metrics.attempts.Observe(float64(n))
+	s.metrics.otelAttempts.Record(context.Background(), n)
+}
+
+// countBudgetExceeded counts a cap tripping.
+func (s *server) countBudgetExceeded(e *molecular.Error) {
+	name, _ := e.Details["cap"].(string)
+	s.metrics.budgetExceeded.Inc(name)
+	s.metrics.otelBudgetExceeded.Add(context.Background(), 1, attribute.String("silicon.limits.cap", name))
+}
+
+// withMetrics records the latency of every request, labelled with the
+// route pattern that serves it so task IDs do not multiply series.
+func (s *server) withMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		method := semconv.HTTPMethodKey.String(r.Method)
+		s.metrics.otelHTTPActive.Add(ctx, 1, method)
+		defer s.metrics.otelHTTPActive.Add(ctx, -1, method)
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		elapsed := time.Since(start)
+		_, pattern := s.mux.Handler(r)
+		_, route, _ := strings.Cut(pattern, " ")
+		if pattern == "/" {
+			route = "unmatched"
+		}
+		s.metrics.httpDuration.Observe(elapsed.Seconds(), r.Method, route, strconv.Itoa(rec.status))
+		s.metrics.otelHTTPDuration.Record(ctx, float64(elapsed)/float64(time.Millisecond),
+			method, semconv.HTTPRouteKey.String(route), semconv.HTTPStatusCodeKey.Int(rec.status))
+	})
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/internal/config"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	s := newServer(artifacts.New(t.TempDir()))
+	srv := httptest.NewServer(s.withMetrics(s))
+	defer srv.Close()
+
+	post := func(path, body string) int {
+		t.Helper()
+		resp, err := http.Post(srv.URL+path, "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("post %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := post("/v1/tasks", `{"task_id":"t1","prompt":"p"}`); code != http.StatusCreated {
+		t.Fatalf("create: %d", code)
+	}
+	waitFinished(t, s, "t1")
+	// today's usage is already over the daily token cap
+	s.mu.Lock()
+	s.limits.Daily = config.Caps{MaxTokens: 1}
+	s.dailyLocked(time.Now()).tokens = 5
+	s.mu.Unlock()
+	if code := post("/v1/tasks", `{"task_id":"t2","prompt":"p"}`); code != http.StatusTooManyRequests {
+		t.Fatalf("expected the daily cap to refuse the submit, got %d", code)
+	}
+	if code := post("/v1/nope", `{}`); code != http.StatusNotFound {
+		t.Fatalf("unknown route: %d", code)
+	}
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("scrape: %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	body := string(b)
+	for _, want := range []string{
+		`silicon_tasks{status="completed"} 1`,
+		"silicon_queue_depth 0",
+		`silicon_phase_duration_seconds_count{phase="pending"} 1`,
+		`silicon_phase_duration_seconds_count{phase="executing"} 1`,
+		"silicon_attempts_per_task_bucket{le=\"1\"} 1",
+		`silicon_budget_exceeded_total{cap="daily.max_tokens"} 1`,
+		`silicon_http_request_duration_seconds_count{method="POST",route="/v1/tasks",code="201"} 1`,
+		`silicon_http_request_duration_seconds_count{method="POST",route="/v1/tasks",code="429"} 1`,
+		`silicon_http_request_duration_seconds_count{method="POST",route="unmatched",code="404"} 1`,
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("metrics lack %q", want)
+		}
+	}
+	if t.Failed() {
+		t.Logf("metrics:\n%s", body)
+	}
+}
+
+func TestOTelMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	oldProvider := meterProvider
+	meterProvider = func() metric.MeterProvider { return mp }
+	defer func() { meterProvider = oldProvider }()
+
+	s := newServer(artifacts.New(t.TempDir()))
+	srv := httptest.NewServer(s.withMetrics(s))
+	defer srv.Close()
+	resp, err := http.Post(srv.URL+"/v1/tasks", "application/json", bytes.NewBufferString(`{"task_id":"t1","prompt":"p"}`))
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: %v %v", resp, err)
+	}
+	resp.Body.Close()
+	waitFinished(t, s, "t1")
+
+	rm, err := reader.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	got := map[string]metricdata.Metrics{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			got[m.Name] = m
+		}
+	}
+	for _, name := range []string{"silicon.tasks", "silicon.tasks.pending", "silicon.task.phase.duration", "silicon.task.attempts", "http.server.duration", "http.server.active_requests"} {
+		if _, ok := got[name]; !ok {
+			t.Errorf("missing instrument %s", name)
+		}
+	}
+	if tasks, ok := got["silicon.tasks"].Data.(metricdata.Sum[int64]); !ok || len(tasks.DataPoints) != 1 || tasks.DataPoints[0].Value != 1 {
+		t.Errorf("silicon.tasks: %+v", got["silicon.tasks"].Data)
+	} else if v, _ := tasks.DataPoints[0].Attributes.Value("silicon.task.status"); v.AsString() != "completed" {
+		t.Errorf("silicon.tasks status: %v", v.AsString())
+	}
+	if h, ok := got["http.server.duration"].Data.(metricdata.Histogram); !ok || len(h.DataPoints) != 1 {
+		t.Errorf("http.server.duration: %+v", got["http.server.duration"].Data)
+	} else if v, _ := h.DataPoints[0].Attributes.Value("http.route"); v.AsString() != "/v1/tasks" || got["http.server.duration"].Unit != "ms" {
+		t.Errorf("http.server.duration route %q unit %q", v.AsString(), got["http.server.duration"].Unit)
+	}
+}
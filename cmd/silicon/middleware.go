@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/throw-if-null/molecular/internal/auth"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// statusRecorder captures the response status for span attributes.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter { return r.ResponseWriter }
+
+// withTracing wraps every request in a silicon.http.request span so that
+// middleware and handlers can attach events to it.
+func withTracing(next http.Handler) http.Handler {
+	tr := otel.Tracer("silicon")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tr.Start(
+			r.Context(),
+			"silicon.http.request",
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(r.Method),
+				semconv.HTTPTargetKey.String(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+// withAuth requires a bearer token from k on every request. GET and HEAD
+// need the read scope; everything else needs write. Failures are recorded
+// as auth.failed events on the request span.
+func withAuth(k *auth.Keyring, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		need := auth.ScopeWrite
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			need = auth.ScopeRead
+		}
+
+		tok, err := k.Authenticate(auth.BearerToken(r.Header.Get("Authorization")))
+		if err != nil {
+			reason := "invalid_token"
+			switch {
+			case errors.Is(err, auth.ErrNoToken):
+				reason = "missing_token"
+			case !errors.Is(err, auth.ErrInvalidToken):
+				span.RecordError(err)
+				writeError(w, r, http.StatusInternalServerError, molecular.CodeInternal, "authentication unavailable", nil)
+				return
+			}
+			span.AddEvent("auth.failed", trace.WithAttributes(attribute.String("silicon.auth.reason", reason)))
+			w.Header().Set("WWW-Authenticate", `Bearer realm="silicon"`)
+			writeError(w, r, http.StatusUnauthorized, molecular.CodeUnauthorized, "missing or invalid bearer token", nil)
+			return
+		}
+		span.SetAttributes(attribute.String("silicon.auth.token_id", tok.ID))
+		if !tok.Scope.Allows(need) {
+			span.AddEvent("auth.failed", trace.WithAttributes(
+				attribute.String("silicon.auth.reason", "insufficient_scope"),
+				attribute.String("silicon.auth.scope", string(tok.Scope)),
+			))
+			writeError(w, r, http.StatusForbidden, molecular.CodeForbidden, "token lacks "+string(need)+" scope", map[string]any{"required_scope": string(need)})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withRecovery turns a handler panic into a 500 response and records it on
+// the request span. http.ErrAbortHandler is re-panicked so net/http can
+// abort the connection as intended.
+func withRecovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			err, ok := v.(error)
+			if !ok {
+				err = fmt.Errorf("panic: %v", v)
+			}
+			stack := string(debug.Stack())
+			span := trace.SpanFromContext(r.Context())
+			span.RecordError(err, trace.WithAttributes(attribute.String("exception.stacktrace", stack)))
+			span.AddEvent("http.panic")
+			span.SetStatus(codes.Error, err.Error())
+			slog.Error("handler panic", "method", r.Method, "path", r.URL.Path, "err", err, "stack", stack)
+			if rec.status == 0 {
+				writeError(rec, r, http.StatusInternalServerError, molecular.CodeInternal, "internal server error", nil)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// maxJSONBody caps the size of JSON request bodies.
+const maxJSONBody = 1 << 20
+
+// limitBody caps r.Body at maxJSONBody bytes; reading past the limit fails
+// with *http.MaxBytesError, which handlers report as 413.
+func limitBody(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxJSONBody)
+}
+
+// decodeBody decodes a size-limited JSON request body into v. On failure
+// it writes the error response and returns false.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	limitBody(w, r)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, r, http.StatusRequestEntityTooLarge, molecular.CodeRequestTooLarge, "request body too large", map[string]any{"limit_bytes": tooLarge.Limit})
+			return false
+		}
+		writeError(w, r, http.StatusBadRequest, molecular.CodeInvalidRequest, "invalid JSON: "+err.Error(), nil)
+		return false
+	}
+	return true
+}
+
+// streamResponse lifts the server's write timeout for handlers that send
+// arbitrarily large or long-lived bodies.
+func streamResponse(w http.ResponseWriter) {
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+}
+
+// newHTTPServer wraps handler in an http.Server with conservative limits.
+// WriteTimeout bounds ordinary JSON responses; streaming handlers opt out
+// via streamResponse.
+func newHTTPServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		MaxHeaderBytes:    1 << 20,
+	}
+}
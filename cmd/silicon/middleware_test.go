@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/internal/auth"
+	"github.com/throw-if-null/molecular/internal/state"
+	"github.com/throw-if-null/molecular/internal/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// useTestTracer installs an in-memory tracer via telemetryInit and
+// restores the previous globals when the test ends.
+func useTestTracer(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	prev := otel.GetTracerProvider()
+	oldInit, oldDot := telemetryInit, dotenvLoad
+	telemetryInit = func(ctx context.Context, cfg telemetry.Config) (func(context.Context) error, error) {
+		otel.SetTracerProvider(tp)
+		return tp.Shutdown, nil
+	}
+	dotenvLoad = func(...string) error { return nil }
+	t.Cleanup(func() {
+		telemetryInit, dotenvLoad = oldInit, oldDot
+		otel.SetTracerProvider(prev)
+	})
+	return exp
+}
+
+func TestAuth_Middleware(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(state.EnvDir, dir)
+	exp := useTestTracer(t)
+
+	readTok, rec, _ := auth.NewToken("reader", auth.ScopeRead)
+	if err := auth.Add(auth.TokensPath(dir), rec); err != nil {
+		t.Fatal(err)
+	}
+	writeTok, rec, _ := auth.NewToken("writer", auth.ScopeWrite)
+	if err := auth.Add(auth.TokensPath(dir), rec); err != nil {
+		t.Fatal(err)
+	}
+
+	handler, shutdown, err := setup(context.Background(), options{auth: true})
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	defer shutdown(context.Background())
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	do := func(method, path, token string) int {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(`{"task_id":"t1","prompt":"p"}`))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := do(http.MethodGet, "/v1/tasks", ""); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", code)
+	}
+	if code := do(http.MethodGet, "/v1/tasks", "mol_wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for unknown token, got %d", code)
+	}
+	if code := do(http.MethodGet, "/v1/tasks", readTok); code != http.StatusOK {
+		t.Fatalf("expected 200 for read token, got %d", code)
+	}
+	if code := do(http.MethodPost, "/v1/tasks", readTok); code != http.StatusForbidden {
+		t.Fatalf("expected 403 for read token on submit, got %d", code)
+	}
+	if code := do(http.MethodPost, "/v1/tasks", writeTok); code != http.StatusCreated {
+		t.Fatalf("expected 201 for write token on submit, got %d", code)
+	}
+
+	reasons := map[string]int{}
+	for _, s := range exp.GetSpans() {
+		if s.Name != "silicon.http.request" {
+			continue
+		}
+		for _, ev := range s.Events {
+			if ev.Name != "auth.failed" {
+				continue
+			}
+			for _, a := range ev.Attributes {
+				if a.Key == attribute.Key("silicon.auth.reason") {
+					reasons[a.Value.AsString()]++
+				}
+			}
+		}
+	}
+	if reasons["missing_token"] != 1 || reasons["invalid_token"] != 1 || reasons["insufficient_scope"] != 1 {
+		t.Fatalf("unexpected auth.failed events: %v", reasons)
+	}
+}
+
+func TestAuth_DisabledByDefault(t *testing.T) {
+	t.Setenv(state.EnvDir, t.TempDir())
+	t.Setenv(envAuth, "")
+	useTestTracer(t)
+
+	handler, shutdown, err := setup(context.Background(), options{})
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	defer shutdown(context.Background())
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/v1/tasks")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 without auth, got %d", resp.StatusCode)
+	}
+}
+
+func TestRecovery_PanicReturns500AndRecordsSpan(t *testing.T) {
+	exp := useTestTracer(t)
+	// install the provider directly; this test does not go through setup
+	if _, err := telemetryInit(context.Background(), telemetry.Config{}); err != nil {
+		t.Fatal(err)
+	}
+
+	h := withTracing(withRecovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/v1/tasks")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", resp.StatusCode)
+	}
+
+	spans := exp.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "silicon.http.request" {
+		t.Fatalf("expected one request span, got %+v", spans)
+	}
+	if spans[0].Status.Code != codes.Error {
+		t.Fatalf("expected error status, got %v", spans[0].Status)
+	}
+	var sawPanic, sawException bool
+	for _, ev := range spans[0].Events {
+		switch ev.Name {
+		case "http.panic":
+			sawPanic = true
+		case "exception":
+			sawException = true
+		}
+	}
+	if !sawPanic || !sawException {
+		t.Fatalf("expected http.panic and exception events, got %+v", spans[0].Events)
+	}
+}
+
+func TestCreate_OversizedBodyReturns413(t *testing.T) {
+	srv := httptest.NewServer(newServer(artifacts.New(t.TempDir())))
+	defer srv.Close()
+
+	body := `{"task_id":"big","prompt":"` + strings.Repeat("x", maxJSONBody) + `"}`
+	resp, err := http.Post(srv.URL+"/v1/tasks", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", resp.StatusCode)
+	}
+}
+
+func TestNewHTTPServer_Limits(t *testing.T) {
+	srv := newHTTPServer(http.NotFoundHandler())
+	if srv.ReadHeaderTimeout == 0 || srv.ReadTimeout == 0 || srv.WriteTimeout == 0 || srv.IdleTimeout == 0 || srv.MaxHeaderBytes == 0 {
+		t.Fatalf("expected all limits to be set: %+v", srv)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// retrySpec is what a retry inherits from the task it repeats.
+type retrySpec struct {
+	of      string
+	budgets budgets
+	// worktree is the original's worktree and branch when the retry
+	// continues in them.
+	worktree *worktreeSpec
+	// feedback is the original's notes that no attempt included; the
+	// retry's first Carbon attempt takes them.
+	feedback []molecular.Feedback
+	// prior is what the original and the tasks it retries consumed.
+	prior molecular.Usage
+}
+
+func (s *server) handleRetry(w http.ResponseWriter, r *http.Request, id string) {
+	// the body is optional
+	var req molecular.RetryRequest
+	if r.ContentLength != 0 && !decodeBody(w, r, &req) {
+		return
+	}
+	t, aerr := s.retryTask(r.Context(), id, req)
+	if aerr != nil {
+		writeAPIError(w, r, aerr)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/v1/tasks/"+t.TaskID)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(t)
+}
+
+// retryTask starts a new task with the prompt, repository, base ref,
+// template, labels, metadata, budgets and pending feedback of a finished
+// one, linked to it by retry_of. Unless opts.Fresh is set the retry takes
+// over the original's worktree and branch, so it continues from where the
+// original stopped; the original records the claim in claimed_by and keeps
+// its worktree path for read-only use such as diff.
+func (s *server) retryTask(ctx context.Context, id string, opts molecular.RetryRequest) (molecular.Task, *molecular.Error) {
+	s.mu.Lock()
+	st, ok := s.tasks[id]
+	if !ok {
+		s.mu.Unlock()
+		return molecular.Task{}, errTaskNotFound(id)
+	}
+	if !finishedLocked(st) {
+		status := st.t.Status
+		s.mu.Unlock()
+		return molecular.Task{}, apiError(http.StatusConflict, molecular.CodeTaskRunning, "task is still running", map[string]any{"task_id": id, "status": status})
+	}
+	orig := st.t
+	b, err := overrideBudgets(budgets{orig.CarbonBudget, orig.HeliumBudget, orig.ReviewBudget}, opts.Budgets)
+	if err != nil {
+		s.mu.Unlock()
+		return molecular.Task{}, apiError(http.StatusBadRequest, molecular.CodeInvalidRequest, err.Error(), nil)
+	}
+	from := &retrySpec{of: orig.TaskID, budgets: b, prior: st.prior}
+	if orig.Usage != nil {
+		from.prior.Add(*orig.Usage)
+	}
+	for _, f := range orig.Feedback {
+		if f.Pending() {
+			from.feedback = append(from.feedback, f)
+		}
+	}
+	if !opts.Fresh && orig.RepoPath != "" {
+		if orig.WorktreePath == "" {
+			s.mu.Unlock()
+			return molecular.Task{}, apiError(http.StatusConflict, molecular.CodeNoWorktree, "task has no worktree to continue in; retry with fresh", map[string]any{"task_id": id})
+		}
+		if orig.ClaimedBy != "" {
+			s.mu.Unlock()
+			return molecular.Task{}, apiError(http.StatusConflict, molecular.CodeNoWorktree, "task's worktree was taken over by "+orig.ClaimedBy+"; retry that task or retry with fresh", map[string]any{"task_id": id, "claimed_by": orig.ClaimedBy})
+		}
+		from.worktree = &worktreeSpec{repo: orig.RepoPath, baseRef: orig.BaseRef, commit: orig.BaseCommit, branch: orig.BranchName, path: orig.WorktreePath}
+	}
+	newID := s.retryIDLocked(orig.TaskID)
+	if from.worktree != nil {
+		// claim the worktree so cleanup and other retries leave it alone
+		st.t.ClaimedBy = newID
+	}
+	// the notes go to this retry only
+	takeFeedbackLocked(st, "", newID)
+	s.mu.Unlock()
+
+	req := molecular.CreateTaskRequest{
+		TaskID:         newID,
+		Prompt:         orig.Prompt,
+		RepoPath:       orig.RepoPath,
+		BaseRef:        orig.BaseRef,
+		PromptTemplate: orig.PromptTemplate,
+		Labels:         orig.Labels,
+		Metadata:       orig.Metadata,
+	}
+	t, _, aerr := s.createTask(ctx, req, "", from)
+	if aerr != nil {
+		s.mu.Lock()
+		if from.worktree != nil {
+			st.t.ClaimedBy = ""
+		}
+		takeFeedbackLocked(st, newID, "")
+		s.mu.Unlock()
+	}
+	return t, aerr
+}
+
+// takeFeedbackLocked moves st's notes taken by from over to to: with from
+// empty it takes the pending notes for retry to, with to empty it gives
+// back the notes of a retry that was not created. Callers must hold s.mu.
+func takeFeedbackLocked(st *storedTask, from, to string) {
+	// copy first: task snapshots handed out earlier share the array
+	fb := slices.Clone(st.t.Feedback)
+	for i := range fb {
+		if fb[i].Attempt == 0 && fb[i].TakenBy == from {
+			fb[i].TakenBy = to
+		}
+	}
+	st.t.Feedback = fb
+}
+
+// overrideBudgets applies per-role budget overrides to b.
+func overrideBudgets(b budgets, overrides map[string]int) (budgets, error) {
+	for role, n := range overrides {
+		if n < 1 {
+			return b, fmt.Errorf("budget for %s must be at least 1", role)
+		}
+		switch role {
+		case "carbon":
+			b.carbon = n
+		case "helium":
+			b.helium = n
+		case "review":
+			b.review = n
+		default:
+			return b, fmt.Errorf("unknown budget role %q", role)
+		}
+	}
+	return b, nil
+}
+
+// retryIDLocked picks the first free "<id>-retry-N" task ID, shortening id
+// to keep within the task ID length limit. Callers must hold s.mu.
+func (s *server) retryIDLocked(id string) string {
+	for n := 1; ; n++ {
+		suffix := fmt.Sprintf("-retry-%d", n)
+		base := id
+		if len(base)+len(suffix) > maxTaskIDLen {
+			base = base[:maxTaskIDLen-len(suffix)]
+		}
+		if _, taken := s.tasks[base+suffix]; !taken {
+			return base + suffix
+		}
+	}
+}
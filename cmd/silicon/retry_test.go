@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+func TestRetryTask(t *testing.T) {
+	repo, _ := gitRepo(t)
+	state := t.TempDir()
+	s := newServer(artifacts.New(filepath.Join(state, "tasks")))
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	retry := func(id, body string, v any) *http.Response {
+		t.Helper()
+		resp, err := http.Post(srv.URL+"/v1/tasks/"+id+"/retry", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("retry %s: %v", id, err)
+		}
+		defer resp.Body.Close()
+		_ = json.NewDecoder(resp.Body).Decode(v)
+		return resp
+	}
+
+	b, _ := json.Marshal(molecular.CreateTaskRequest{TaskID: "t1", Prompt: "one", RepoPath: repo, Labels: map[string]string{"epic": "billing"}})
+	resp, err := http.Post(srv.URL+"/v1/tasks", "application/json", bytes.NewReader(b))
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: %v %v", resp, err)
+	}
+	resp.Body.Close()
+	waitFinished(t, s, "t1")
+	wt := filepath.Join(state, "worktrees", "t1")
+	if err := os.WriteFile(filepath.Join(wt, "wip.txt"), []byte("half done\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var e molecular.Error
+	if resp := retry("t1", `{"budgets":{"tokens":5}}`, &e); resp.StatusCode != http.StatusBadRequest || e.Code != molecular.CodeInvalidRequest {
+		t.Fatalf("unknown budget role: %d %+v", resp.StatusCode, e)
+	}
+	if resp := retry("nope", ``, &e); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown task: %d", resp.StatusCode)
+	}
+
+	// by default the retry continues in the original's worktree and branch
+	var r1 molecular.Task
+	resp = retry("t1", `{"budgets":{"carbon":5}}`, &r1)
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("Location") != "/v1/tasks/t1-retry-1" {
+		t.Fatalf("retry: %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if r1.RetryOf != "t1" || r1.WorktreePath != wt || r1.BranchName != "molecular/t1" || r1.Prompt != "one" ||
+		r1.Labels["epic"] != "billing" || r1.CarbonBudget != 5 || r1.HeliumBudget != 3 {
+		t.Fatalf("unexpected retry: %+v", r1)
+	}
+	if _, err := os.Stat(filepath.Join(r1.WorktreePath, "wip.txt")); err != nil {
+		t.Fatalf("retry lost the original's changes: %v", err)
+	}
+	s.mu.Lock()
+	orig := s.tasks["t1"].t
+	s.mu.Unlock()
+	if orig.ClaimedBy != "t1-retry-1" || orig.WorktreePath != wt {
+		t.Fatalf("expected the original to record the claim and keep its path: %+v", orig)
+	}
+
+	// the original's worktree stays readable
+	resp, err = http.Get(srv.URL + "/v1/tasks/t1/diff")
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("diff of the claimed original: %d", resp.StatusCode)
+	}
+
+	// the worktree has moved on, so only a fresh retry is possible
+	if resp := retry("t1", ``, &e); resp.StatusCode != http.StatusConflict || e.Code != molecular.CodeNoWorktree || e.Details["claimed_by"] != "t1-retry-1" {
+		t.Fatalf("second reuse: %d %+v", resp.StatusCode, e)
+	}
+	var r2 molecular.Task
+	if resp := retry("t1", `{"fresh":true}`, &r2); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("fresh retry: %d", resp.StatusCode)
+	}
+	if r2.TaskID != "t1-retry-2" || r2.BranchName != "molecular/t1-retry-2" || r2.WorktreePath != filepath.Join(state, "worktrees", "t1-retry-2") || r2.CarbonBudget != 3 {
+		t.Fatalf("unexpected fresh retry: %+v", r2)
+	}
+
+	// retrying a retry carries its budgets forward
+	waitFinished(t, s, "t1-retry-1")
+	var r3 molecular.Task
+	if resp := retry("t1-retry-1", ``, &r3); resp.StatusCode != http.StatusCreated || r3.RetryOf != "t1-retry-1" || r3.CarbonBudget != 5 || r3.WorktreePath != wt {
+		t.Fatalf("retry of retry: %d %+v", resp.StatusCode, r3)
+	}
+
+	// cleaning up the original leaves the claimed worktree in place
+	if res, aerr := s.cleanupTask(context.Background(), "t1"); aerr != nil || res.Worktree {
+		t.Fatalf("cleanup of the original: %+v %v", res, aerr)
+	}
+	if _, err := os.Stat(filepath.Join(wt, "wip.txt")); err != nil {
+		t.Fatalf("cleanup removed the claimed worktree: %v", err)
+	}
+
+	// removing it with the last retry clears the path along the chain
+	waitFinished(t, s, r3.TaskID)
+	if res, aerr := s.cleanupTask(context.Background(), r3.TaskID); aerr != nil || !res.Worktree {
+		t.Fatalf("cleanup of the last retry: %+v %v", res, aerr)
+	}
+	s.mu.Lock()
+	paths := []string{s.tasks["t1"].t.WorktreePath, s.tasks["t1-retry-1"].t.WorktreePath}
+	s.mu.Unlock()
+	if paths[0] != "" || paths[1] != "" {
+		t.Fatalf("stale worktree paths after cleanup: %q", paths)
+	}
+}
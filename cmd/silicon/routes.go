@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/throw-if-null/molecular/internal/api"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// route binds a method and path to a handler. Paths use net/http.ServeMux
+// pattern syntax; a trailing "..." wildcard matches the rest of the path.
+// Every route must be described in internal/api/openapi.json.
+type route struct {
+	method  string
+	path    string
+	handler http.HandlerFunc
+}
+
+// routes returns Silicon's API routes.
+func (s *server) routes() []route {
+	return []route{
+		{http.MethodGet, "/v1/openapi.json", handleOpenAPI},
+		{http.MethodGet, "/metrics", s.handleMetrics},
+		{http.MethodGet, "/v1/tasks", s.handleList},
+		{http.MethodPost, "/v1/tasks", s.handleCreate},
+		{http.MethodGet, "/v1/tasks/{id}", withTaskID(s.handleGet)},
+		{http.MethodPost, "/v1/tasks/{id}/cancel", withTaskID(s.handleCancel)},
+		{http.MethodPost, "/v1/tasks/{id}/retry", withTaskID(s.handleRetry)},
+		{http.MethodPost, "/v1/tasks/{id}/feedback", withTaskID(s.handleFeedback)},
+		{http.MethodPost, "/v1/tasks:batchCancel", s.handleBatch("cancel", s.batchCancelOp)},
+		{http.MethodPost, "/v1/tasks:batchCleanup", s.handleBatch("cleanup", s.batchCleanupOp)},
+		{http.MethodPost, "/v1/tasks:batchRetry", s.handleBatch("retry", s.batchRetryOp)},
+		{http.MethodPost, "/v1/tasks/{id}/cleanup", withTaskID(s.handleCleanup)},
+		{http.MethodGet, "/v1/tasks/{id}/logs", withTaskID(s.handleLogs)},
+		{http.MethodGet, "/v1/tasks/{id}/events", withTaskID(s.handleEvents)},
+		{http.MethodGet, "/v1/tasks/{id}/artifacts", withTaskID(s.handleArtifactsList)},
+		{http.MethodGet, "/v1/tasks/{id}/artifacts/{path...}", func(w http.ResponseWriter, r *http.Request) {
+			s.handleArtifactGet(w, r, r.PathValue("id"), r.PathValue("path"))
+		}},
+		{http.MethodGet, "/v1/tasks/{id}/diff", withTaskID(s.handleDiff)},
+	}
+}
+
+// newMux registers routes on a ServeMux. Unknown paths and methods get the
+// JSON not_found error rather than ServeMux's plain-text replies.
+func newMux(routes []route) *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, rt := range routes {
+		mux.HandleFunc(rt.method+" "+rt.path, rt.handler)
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, http.StatusNotFound, molecular.CodeNotFound, "no route for "+r.Method+" "+r.URL.Path, nil)
+	})
+	return mux
+}
+
+// withTaskID adapts a handler taking the {id} path value.
+func withTaskID(h func(http.ResponseWriter, *http.Request, string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h(w, r, r.PathValue("id"))
+	}
+}
+
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(api.OpenAPI)
+}
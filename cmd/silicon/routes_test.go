@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/throw-if-null/molecular/internal/api"
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// TestRoutesMatchOpenAPI fails when a route is added, removed or changed
+// without updating internal/api/openapi.json, or the other way round.
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(api.OpenAPI, &doc); err != nil {
+		t.Fatalf("parse openapi.json: %v", err)
+	}
+	var spec []string
+	for p, item := range doc.Paths {
+		for method := range item {
+			switch method {
+			case "get", "put", "post", "delete", "patch", "head", "options":
+				spec = append(spec, strings.ToUpper(method)+" "+p)
+			}
+		}
+	}
+
+	var served []string
+	for _, rt := range newServer(artifacts.New(t.TempDir())).routes() {
+		// OpenAPI has no multi-segment parameters; {path...} is {path}.
+		served = append(served, rt.method+" "+strings.ReplaceAll(rt.path, "...}", "}"))
+	}
+
+	sort.Strings(spec)
+	sort.Strings(served)
+	if strings.Join(spec, "\n") != strings.Join(served, "\n") {
+		t.Fatalf("routes and openapi.json differ\nspec:\n  %s\nserved:\n  %s",
+			strings.Join(spec, "\n  "), strings.Join(served, "\n  "))
+	}
+}
+
+func TestOpenAPIEndpointAndUnknownRoutes(t *testing.T) {
+	srv := httptest.NewServer(newServer(artifacts.New(t.TempDir())))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/v1/openapi.json")
+	if err != nil {
+		t.Fatalf("get openapi: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected response: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if !bytes.Equal(body, api.OpenAPI) {
+		t.Fatalf("served document differs from the embedded one")
+	}
+
+	// wrong method and unknown path both get the JSON error envelope
+	for _, c := range []struct{ method, path string }{
+		{http.MethodDelete, "/v1/tasks"},
+		{http.MethodGet, "/v1/nope"},
+	} {
+		req, _ := http.NewRequest(c.method, srv.URL+c.path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", c.method, c.path, err)
+		}
+		var e molecular.Error
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound || e.Code != molecular.CodeNotFound {
+			t.Fatalf("%s %s: got %d %q", c.method, c.path, resp.StatusCode, e.Code)
+		}
+	}
+}
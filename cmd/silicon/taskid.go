@@ -0,0 +1,51 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// maxSlugLen bounds the prompt-derived part of generated task IDs.
+const maxSlugLen = 40
+
+// newTaskID derives a readable ID from prompt: up to a few lowercase words
+// joined by dashes, followed by a short random suffix.
+func newTaskID(prompt string) string {
+	return slugify(prompt) + "-" + randomSuffix()
+}
+
+func slugify(prompt string) string {
+	var b strings.Builder
+	words := 0
+	dash := false
+	for _, r := range strings.ToLower(prompt) {
+		alnum := (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')
+		if !alnum {
+			dash = b.Len() > 0
+			continue
+		}
+		if dash {
+			words++
+			if words >= 6 || b.Len()+2 > maxSlugLen {
+				break
+			}
+			b.WriteByte('-')
+			dash = false
+		}
+		if b.Len()+1 > maxSlugLen {
+			break
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 {
+		return "task"
+	}
+	return strings.TrimRight(b.String(), "-")
+}
+
+func randomSuffix() string {
+	var buf [3]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"Fix the NPE in parser.go!":         "fix-the-npe-in-parser-go",
+		"   ":                               "task",
+		"Ünïcode only":                      "n-code-only",
+		"one two three four five six seven": "one-two-three-four-five-six",
+		"averyveryveryveryveryveryveryverylongwordindeed": "averyveryveryveryveryveryveryverylongwor",
+	}
+	for in, want := range cases {
+		if got := slugify(in); got != want {
+			t.Errorf("slugify(%q) = %q, want %q", in, got, want)
+		}
+		if !validTaskID(newTaskID(in)) {
+			t.Errorf("newTaskID(%q) is not a valid task id", in)
+		}
+	}
+}
+
+func TestCreate_GeneratedIDAndIdempotency(t *testing.T) {
+	srv := httptest.NewServer(newServer(artifacts.New(t.TempDir())))
+	defer srv.Close()
+
+	post := func(req molecular.CreateTaskRequest, key string) (*http.Response, molecular.Task) {
+		t.Helper()
+		b, _ := json.Marshal(req)
+		hreq, _ := http.NewRequest(http.MethodPost, srv.URL+"/v1/tasks", bytes.NewReader(b))
+		hreq.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			hreq.Header.Set(molecular.IdempotencyKeyHeader, key)
+		}
+		resp, err := http.DefaultClient.Do(hreq)
+		if err != nil {
+			t.Fatalf("post create: %v", err)
+		}
+		defer resp.Body.Close()
+		var got molecular.Task
+		_ = json.NewDecoder(resp.Body).Decode(&got)
+		return resp, got
+	}
+
+	resp, got := post(molecular.CreateTaskRequest{Prompt: "Add retry to the uploader"}, "")
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	if !regexp.MustCompile(`^add-retry-to-the-uploader-[0-9a-f]{6}$`).MatchString(got.TaskID) {
+		t.Fatalf("unexpected generated id: %q", got.TaskID)
+	}
+	if loc := resp.Header.Get("Location"); loc != "/v1/tasks/"+got.TaskID {
+		t.Fatalf("unexpected Location: %q", loc)
+	}
+
+	// idempotency key: the retry returns the first task
+	resp, first := post(molecular.CreateTaskRequest{Prompt: "same"}, "k1")
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	resp, again := post(molecular.CreateTaskRequest{Prompt: "same"}, "k1")
+	if resp.StatusCode != http.StatusOK || again.TaskID != first.TaskID {
+		t.Fatalf("expected 200 with %q, got %d %q", first.TaskID, resp.StatusCode, again.TaskID)
+	}
+
+	// explicit id: conflict unless if_not_exists is set
+	if resp, _ := post(molecular.CreateTaskRequest{TaskID: "fixed", Prompt: "x"}, ""); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	if resp, _ := post(molecular.CreateTaskRequest{TaskID: "fixed", Prompt: "x"}, ""); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", resp.StatusCode)
+	}
+	resp, got = post(molecular.CreateTaskRequest{TaskID: "fixed", Prompt: "x", IfNotExists: true}, "")
+	if resp.StatusCode != http.StatusOK || got.TaskID != "fixed" {
+		t.Fatalf("expected 200 with existing task, got %d %q", resp.StatusCode, got.TaskID)
+	}
+}
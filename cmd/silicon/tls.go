@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Environment variables consulted when the matching TLS flag is not set.
+const (
+	envTLSCert     = "SILICON_TLS_CERT"
+	envTLSKey      = "SILICON_TLS_KEY"
+	envTLSClientCA = "SILICON_TLS_CLIENT_CA"
+)
+
+// tlsOptions names the PEM files Silicon serves HTTPS with. Setting
+// clientCA turns on mutual TLS.
+type tlsOptions struct {
+	cert     string
+	key      string
+	clientCA string
+}
+
+// withEnv fills unset fields from the environment.
+func (o tlsOptions) withEnv() tlsOptions {
+	if o.cert == "" {
+		o.cert = os.Getenv(envTLSCert)
+	}
+	if o.key == "" {
+		o.key = os.Getenv(envTLSKey)
+	}
+	if o.clientCA == "" {
+		o.clientCA = os.Getenv(envTLSClientCA)
+	}
+	return o
+}
+
+// serverTLSConfig builds the listener TLS config. It returns nil when no
+// certificate is configured, i.e. Silicon serves plain HTTP.
+func serverTLSConfig(o tlsOptions) (*tls.Config, error) {
+	if o.cert == "" && o.key == "" {
+		if o.clientCA != "" {
+			return nil, errors.New("client CA requires a server certificate and key")
+		}
+		return nil, nil
+	}
+	if o.cert == "" || o.key == "" {
+		return nil, errors.New("TLS needs both a certificate and a key")
+	}
+	pair, err := tls.LoadX509KeyPair(o.cert, o.key)
+	if err != nil {
+		return nil, fmt.Errorf("loading TLS key pair: %w", err)
+	}
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{pair},
+	}
+	if o.clientCA != "" {
+		pem, err := os.ReadFile(o.clientCA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", o.clientCA)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
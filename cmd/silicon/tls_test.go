@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/internal/devcerts"
+)
+
+func TestServerTLSConfig_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	if err := devcerts.Generate(dir, []string{"127.0.0.1"}); err != nil {
+		t.Fatalf("generate certs: %v", err)
+	}
+	f := func(name string) string { return filepath.Join(dir, name) }
+
+	cfg, err := serverTLSConfig(tlsOptions{cert: f(devcerts.ServerCert), key: f(devcerts.ServerKey), clientCA: f(devcerts.CACert)})
+	if err != nil {
+		t.Fatalf("tls config: %v", err)
+	}
+	ln, err := listen("127.0.0.1:0", 0)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := &http.Server{Handler: newServer(artifacts.New(t.TempDir()))}
+	go srv.Serve(tls.NewListener(ln, cfg))
+	defer srv.Close()
+
+	pool := x509.NewCertPool()
+	caPEM, _ := os.ReadFile(f(devcerts.CACert))
+	pool.AppendCertsFromPEM(caPEM)
+	pair, err := tls.LoadX509KeyPair(f(devcerts.ClientCert), f(devcerts.ClientKey))
+	if err != nil {
+		t.Fatalf("client pair: %v", err)
+	}
+	url := "https://" + ln.Addr().String() + "/v1/tasks"
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{pair}}}}
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("mTLS get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	}
+
+	noCert := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	if resp, err := noCert.Get(url); err == nil {
+		resp.Body.Close()
+		t.Fatalf("expected rejection without client certificate")
+	}
+}
+
+func TestServerTLSConfig_Validation(t *testing.T) {
+	if cfg, err := serverTLSConfig(tlsOptions{}); cfg != nil || err != nil {
+		t.Fatalf("expected plain HTTP without certs, got %v %v", cfg, err)
+	}
+	if _, err := serverTLSConfig(tlsOptions{cert: "cert.pem"}); err == nil {
+		t.Fatalf("expected error for cert without key")
+	}
+	if _, err := serverTLSConfig(tlsOptions{clientCA: "ca.pem"}); err == nil {
+		t.Fatalf("expected error for client CA without server cert")
+	}
+	if _, err := serverTLSConfig(tlsOptions{cert: "missing.pem", key: "missing-key.pem"}); err == nil {
+		t.Fatalf("expected error for missing files")
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/internal/task"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+func TestRun_AggregatesUsage(t *testing.T) {
+	s := newServer(artifacts.New(t.TempDir()))
+	// the agent prints its usage; the runner reports it
+	s.runner = task.Runner{Command: `cat >/dev/null; echo '{"model":"m2","input_tokens":100,"output_tokens":10,"cost_usd":0.25}'`}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	st := &storedTask{t: molecular.Task{TaskID: "t1", Prompt: "p", Status: "running"}, ctx: ctx, cancel: cancel}
+	// an earlier attempt on another model
+	addUsageLocked(st, molecular.Usage{Model: "m1", InputTokens: 1, OutputTokens: 2, CostUSD: 0.5})
+	s.tasks["t1"] = st
+	s.run(st)
+
+	if a := st.t.LatestAttempt; a == nil || a.Usage == nil || a.Usage.Model != "m2" || a.Usage.InputTokens != 100 {
+		t.Fatalf("attempt usage: %+v", st.t.LatestAttempt)
+	}
+	if *st.t.Usage != (molecular.Usage{InputTokens: 101, OutputTokens: 12, CostUSD: 0.75}) {
+		t.Fatalf("task usage: %+v", st.t.Usage)
+	}
+	addUsageLocked(st, molecular.Usage{Model: "m1", InputTokens: 1})
+	if by := st.t.UsageByModel; len(by) != 2 || by[0].Model != "m1" || by[0].InputTokens != 2 || by[1].Model != "m2" || by[1].CostUSD != 0.25 {
+		t.Fatalf("usage by model: %+v", by)
+	}
+}
+
+func TestRun_AgentFailed(t *testing.T) {
+	s := newServer(artifacts.New(t.TempDir()))
+	s.runner = task.Runner{Command: `echo '{"input_tokens":7,"output_tokens":1}'; exit 1`}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	st := &storedTask{t: molecular.Task{TaskID: "t1", Prompt: "p", Status: "running"}, ctx: ctx, cancel: cancel}
+	s.tasks["t1"] = st
+	s.run(st)
+
+	if st.t.Status != statusFailed || st.t.Error == nil || st.t.Error.Code != molecular.CodeAgentFailed {
+		t.Fatalf("expected the task failed by its agent: %+v %+v", st.t, st.t.Error)
+	}
+	if a := st.t.LatestAttempt; a.Status != statusFailed || a.ErrorSummary == "" || st.t.Usage == nil || st.t.Usage.InputTokens != 7 {
+		t.Fatalf("expected the failed attempt and its usage: %+v %+v", a, st.t.Usage)
+	}
+	if ev := st.events[len(st.events)-1]; ev.Type != molecular.EventTaskFailed {
+		t.Fatalf("expected task.failed, got %s", ev.Type)
+	}
+}
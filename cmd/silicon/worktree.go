@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/throw-if-null/molecular/internal/git"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// worktreeSpec is the validated git placement for a new task.
+type worktreeSpec struct {
+	repo    string
+	baseRef string
+	commit  string
+	branch  string
+	// path is an existing worktree a retry continues in; empty checks
+	// out a new one.
+	path string
+}
+
+// requestError carries the HTTP status and error code a validation
+// failure maps to.
+type requestError struct {
+	status int
+	code   molecular.ErrorCode
+	msg    string
+}
+
+func (e *requestError) Error() string { return e.msg }
+
+// resolveWorktree validates the git fields of req against the local
+// repository. It returns nil when the request does not ask for a worktree.
+func resolveWorktree(ctx context.Context, req molecular.CreateTaskRequest) (*worktreeSpec, error) {
+	if req.RepoPath == "" {
+		if req.BaseRef != "" || req.BranchName != "" {
+			return nil, &requestError{http.StatusBadRequest, molecular.CodeInvalidRequest, "repo_path required with base_ref or branch_name"}
+		}
+		return nil, nil
+	}
+	if !filepath.IsAbs(req.RepoPath) {
+		return nil, &requestError{http.StatusBadRequest, molecular.CodeInvalidRequest, "repo_path must be absolute"}
+	}
+	repo, err := git.TopLevel(ctx, req.RepoPath)
+	if err != nil {
+		return nil, &requestError{http.StatusBadRequest, molecular.CodeInvalidRequest, "repo_path is not a git repository"}
+	}
+
+	spec := &worktreeSpec{repo: repo, baseRef: req.BaseRef, branch: req.BranchName}
+	if spec.baseRef == "" {
+		spec.baseRef = "HEAD"
+	}
+	if spec.commit, err = git.ResolveCommit(ctx, repo, spec.baseRef); err != nil {
+		return nil, &requestError{http.StatusBadRequest, molecular.CodeInvalidRequest, fmt.Sprintf("unknown base_ref %q", spec.baseRef)}
+	}
+	if spec.branch == "" {
+		spec.branch = "molecular/" + req.TaskID
+	}
+	if err := git.CheckBranchName(ctx, repo, spec.branch); err != nil {
+		return nil, &requestError{http.StatusBadRequest, molecular.CodeInvalidRequest, fmt.Sprintf("invalid branch_name %q", spec.branch)}
+	}
+	if git.BranchExists(ctx, repo, spec.branch) {
+		return nil, &requestError{http.StatusConflict, molecular.CodeBranchExists, fmt.Sprintf("branch %q exists", spec.branch)}
+	}
+	return spec, nil
+}
+
+// createWorktree checks out spec into the server's worktree root and
+// returns the worktree path.
+func (s *server) createWorktree(ctx context.Context, taskID string, spec *worktreeSpec) (string, error) {
+	path := filepath.Join(s.worktreeRoot, taskID)
+	if err := git.AddWorktree(ctx, spec.repo, path, spec.branch, spec.commit); err != nil {
+		var gerr *git.Error
+		if errors.As(err, &gerr) {
+			return "", fmt.Errorf("creating worktree: %s", gerr.Stderr)
+		}
+		return "", err
+	}
+	return path, nil
+}
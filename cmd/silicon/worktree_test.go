@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+func TestCreate_WithWorktree(t *testing.T) {
+	repo, _ := gitRepo(t)
+	gitRun(t, repo, "branch", "release/1.4")
+	if err := os.WriteFile(filepath.Join(repo, "b.txt"), []byte("main only\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, repo, "add", "-A")
+	gitRun(t, repo, "commit", "-q", "-m", "main only")
+	release := gitRun(t, repo, "rev-parse", "release/1.4")
+
+	state := t.TempDir()
+	s := newServer(artifacts.New(filepath.Join(state, "tasks")))
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	post := func(req molecular.CreateTaskRequest) (*http.Response, molecular.Task) {
+		t.Helper()
+		b, _ := json.Marshal(req)
+		resp, err := http.Post(srv.URL+"/v1/tasks", "application/json", bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("post create: %v", err)
+		}
+		defer resp.Body.Close()
+		var got molecular.Task
+		_ = json.NewDecoder(resp.Body).Decode(&got)
+		return resp, got
+	}
+
+	resp, got := post(molecular.CreateTaskRequest{TaskID: "task-1", Prompt: "x", RepoPath: repo, BaseRef: "release/1.4", BranchName: "feature/x"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: %d", resp.StatusCode)
+	}
+	if got.BaseRef != "release/1.4" || got.BaseCommit != release || got.BranchName != "feature/x" {
+		t.Fatalf("unexpected git fields: %+v", got)
+	}
+	if got.WorktreePath != filepath.Join(state, "worktrees", "task-1") {
+		t.Fatalf("unexpected worktree path: %q", got.WorktreePath)
+	}
+	if _, err := os.Stat(filepath.Join(got.WorktreePath, "b.txt")); !os.IsNotExist(err) {
+		t.Fatalf("worktree should be based on release/1.4, b.txt stat err=%v", err)
+	}
+	if head := gitRun(t, got.WorktreePath, "rev-parse", "--abbrev-ref", "HEAD"); head != "feature/x" {
+		t.Fatalf("unexpected worktree branch: %q", head)
+	}
+
+	// default branch name and base ref
+	resp, got = post(molecular.CreateTaskRequest{TaskID: "task-2", Prompt: "x", RepoPath: repo})
+	if resp.StatusCode != http.StatusCreated || got.BranchName != "molecular/task-2" || got.BaseRef != "HEAD" {
+		t.Fatalf("unexpected defaults: %d %+v", resp.StatusCode, got)
+	}
+
+	cases := []struct {
+		req  molecular.CreateTaskRequest
+		code int
+	}{
+		{molecular.CreateTaskRequest{TaskID: "bad-1", BaseRef: "main"}, http.StatusBadRequest},
+		{molecular.CreateTaskRequest{TaskID: "bad-2", RepoPath: "relative/path"}, http.StatusBadRequest},
+		{molecular.CreateTaskRequest{TaskID: "bad-3", RepoPath: t.TempDir()}, http.StatusBadRequest},
+		{molecular.CreateTaskRequest{TaskID: "bad-4", RepoPath: repo, BaseRef: "nope"}, http.StatusBadRequest},
+		{molecular.CreateTaskRequest{TaskID: "bad-5", RepoPath: repo, BranchName: "bad..name"}, http.StatusBadRequest},
+		{molecular.CreateTaskRequest{TaskID: "bad-6", RepoPath: repo, BranchName: "feature/x"}, http.StatusConflict},
+	}
+	for _, c := range cases {
+		if resp, _ := post(c.req); resp.StatusCode != c.code {
+			t.Fatalf("%+v: expected %d, got %d", c.req, c.code, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// UnixScheme prefixes addresses that name a Unix domain socket, as in
+// unix:///run/user/1000/silicon.sock.
+const UnixScheme = "unix://"
+
+// DefaultAddress is Silicon's default TCP listen address.
+func DefaultAddress() string {
+	return fmt.Sprintf("%s:%d", DefaultHost, DefaultPort)
+}
+
+// ParseAddress splits a Silicon address into a network ("tcp" or "unix")
+// and a dialable address. Accepted forms are host:port, http://host:port,
+// https://host:port and unix:///abs/path.sock.
+func ParseAddress(s string) (network, address string, err error) {
+	if p, ok := strings.CutPrefix(s, UnixScheme); ok {
+		if !strings.HasPrefix(p, "/") {
+			return "", "", fmt.Errorf("unix socket path must be absolute: %q", s)
+		}
+		return "unix", p, nil
+	}
+	s = strings.TrimPrefix(s, "http://")
+	s = strings.TrimPrefix(s, "https://")
+	s = strings.TrimSuffix(s, "/")
+	if s == "" {
+		return "", "", errors.New("empty address")
+	}
+	if _, _, err := net.SplitHostPort(s); err != nil {
+		return "", "", fmt.Errorf("invalid address %q: %w", s, err)
+	}
+	return "tcp", s, nil
+}
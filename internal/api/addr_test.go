@@ -0,0 +1,37 @@
+package api
+
+import "testing"
+
+func TestParseAddress(t *testing.T) {
+	cases := []struct {
+		in      string
+		network string
+		address string
+		wantErr bool
+	}{
+		{in: "127.0.0.1:8711", network: "tcp", address: "127.0.0.1:8711"},
+		{in: "http://localhost:9000/", network: "tcp", address: "localhost:9000"},
+		{in: ":8711", network: "tcp", address: ":8711"},
+		{in: "https://build-host:8711", network: "tcp", address: "build-host:8711"},
+		{in: "unix:///tmp/silicon.sock", network: "unix", address: "/tmp/silicon.sock"},
+		{in: "unix://relative.sock", wantErr: true},
+		{in: "localhost", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, c := range cases {
+		network, address, err := ParseAddress(c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("ParseAddress(%q): expected error", c.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseAddress(%q): %v", c.in, err)
+			continue
+		}
+		if network != c.network || address != c.address {
+			t.Errorf("ParseAddress(%q) = %q, %q; want %q, %q", c.in, network, address, c.network, c.address)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package api
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI 3 document describing every /v1 endpoint. Silicon
+// serves it at GET /v1/openapi.json; a contract test keeps it in sync with
+// the server's routes.
+//
+//go:embed openapi.json
+var OpenAPI []byte
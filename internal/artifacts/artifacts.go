@@ -0,0 +1,206 @@
+package artifacts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// Well-known directories inside an attempt's artifacts dir.
+const (
+	DirPrompts = "prompts"
+	DirResults = "results"
+	DirDiffs   = "diffs"
+	DirHooks   = "hooks"
+	DirLogs    = "logs"
+)
+
+// TaskLog is the path, relative to the task root, of Silicon's own
+// lifecycle log for a task.
+const TaskLog = "logs/silicon.log"
+
+// ErrInvalidPath is returned when a requested artifact path escapes the
+// task's artifacts root or is otherwise malformed.
+var ErrInvalidPath = errors.New("invalid artifact path")
+
+// Store manages the per-task artifacts tree:
+//
+//	<root>/<task-id>/
+//	  logs/silicon.log
+//	  attempts/<num>-<role>/{prompts,results,diffs,hooks,logs}/
+type Store struct {
+	Root string
+}
+
+// New returns a Store rooted at root.
+func New(root string) *Store {
+	return &Store{Root: root}
+}
+
+// TaskDir returns the artifacts root for a task.
+func (s *Store) TaskDir(taskID string) string {
+	return filepath.Join(s.Root, taskID)
+}
+
+// CreateTask creates the artifacts root for a task and returns its path.
+func (s *Store) CreateTask(taskID string) (string, error) {
+	dir := s.TaskDir(taskID)
+	if err := os.MkdirAll(filepath.Join(dir, DirLogs), 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// AttemptRel returns the attempt directory relative to the task root.
+func AttemptRel(num int64, role string) string {
+	return path.Join("attempts", fmt.Sprintf("%03d-%s", num, role))
+}
+
+// CreateAttempt creates the directory tree for a single attempt and returns
+// its absolute path.
+func (s *Store) CreateAttempt(taskID string, num int64, role string) (string, error) {
+	dir := filepath.Join(s.TaskDir(taskID), filepath.FromSlash(AttemptRel(num, role)))
+	for _, sub := range []string{DirPrompts, DirResults, DirDiffs, DirHooks, DirLogs} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// Write stores data at rel (slash separated, relative to the task root),
+// creating parent directories as needed.
+func (s *Store) Write(taskID, rel string, data []byte) error {
+	p, err := s.resolve(taskID, rel)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0o644)
+}
+
+// Append appends data to the file at rel, creating it if needed.
+func (s *Store) Append(taskID, rel string, data []byte) error {
+	p, err := s.resolve(taskID, rel)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// List walks a task's artifacts root and returns every regular file with
+// its size and SHA-256, sorted by path.
+func (s *Store) List(taskID string) ([]molecular.Artifact, error) {
+	root := s.TaskDir(taskID)
+	out := []molecular.Artifact{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		sum, err := hashFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		out = append(out, molecular.Artifact{
+			Path:    filepath.ToSlash(rel),
+			Size:    info.Size(),
+			SHA256:  sum,
+			ModTime: info.ModTime().UTC().Format(time.RFC3339),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+// Open opens the artifact at rel for reading. The caller must close the
+// returned file. Directories are rejected with fs.ErrNotExist.
+func (s *Store) Open(taskID, rel string) (*os.File, fs.FileInfo, error) {
+	p, err := s.resolve(taskID, rel)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, err
+	}
+	if !info.Mode().IsRegular() {
+		_ = f.Close()
+		return nil, nil, fs.ErrNotExist
+	}
+	return f, info, nil
+}
+
+// Remove deletes a task's entire artifacts tree.
+func (s *Store) Remove(taskID string) error {
+	return os.RemoveAll(s.TaskDir(taskID))
+}
+
+// resolve maps a slash-separated relative path onto the filesystem,
+// rejecting absolute paths and any attempt to leave the task root.
+func (s *Store) resolve(taskID, rel string) (string, error) {
+	if rel == "" || strings.HasPrefix(rel, "/") || strings.Contains(rel, "\\") {
+		return "", ErrInvalidPath
+	}
+	clean := path.Clean(rel)
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", ErrInvalidPath
+	}
+	return filepath.Join(s.TaskDir(taskID), filepath.FromSlash(clean)), nil
+}
+
+func hashFile(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
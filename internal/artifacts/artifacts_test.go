@@ -0,0 +1,83 @@
+package artifacts
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore_WriteListOpen(t *testing.T) {
+	s := New(t.TempDir())
+
+	root, err := s.CreateTask("task-1")
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if root != filepath.Join(s.Root, "task-1") {
+		t.Fatalf("unexpected task root: %q", root)
+	}
+	dir, err := s.CreateAttempt("task-1", 1, "carbon")
+	if err != nil {
+		t.Fatalf("create attempt: %v", err)
+	}
+	if filepath.Base(dir) != "001-carbon" {
+		t.Fatalf("unexpected attempt dir: %q", dir)
+	}
+
+	rel := AttemptRel(1, "carbon") + "/prompts/carbon.md"
+	if err := s.Write("task-1", rel, []byte("hello")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := s.Append("task-1", TaskLog, []byte("a\n")); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if err := s.Append("task-1", TaskLog, []byte("b\n")); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	list, err := s.List("task-1")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 artifacts, got %d: %+v", len(list), list)
+	}
+	// sorted by path: attempts/... before logs/...
+	if list[0].Path != rel {
+		t.Fatalf("unexpected first path: %q", list[0].Path)
+	}
+	// sha256("hello")
+	if list[0].SHA256 != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Fatalf("unexpected hash: %q", list[0].SHA256)
+	}
+	if list[1].Path != TaskLog || list[1].Size != 4 {
+		t.Fatalf("unexpected log artifact: %+v", list[1])
+	}
+
+	f, _, err := s.Open("task-1", TaskLog)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	b, _ := io.ReadAll(f)
+	f.Close()
+	if string(b) != "a\nb\n" {
+		t.Fatalf("unexpected log contents: %q", string(b))
+	}
+}
+
+func TestStore_RejectsEscapingPaths(t *testing.T) {
+	s := New(t.TempDir())
+	if _, err := s.CreateTask("task-1"); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	for _, p := range []string{"", "/etc/passwd", "../task-2/x", "logs/../../x", "..", "a\\b"} {
+		if _, _, err := s.Open("task-1", p); !errors.Is(err, ErrInvalidPath) {
+			t.Fatalf("path %q: expected ErrInvalidPath, got %v", p, err)
+		}
+	}
+	// directories are not downloadable
+	if _, _, err := s.Open("task-1", "logs"); err == nil {
+		t.Fatalf("expected error opening directory")
+	}
+}
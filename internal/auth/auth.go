@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scope limits what a token may do.
+type Scope string
+
+const (
+	// ScopeRead allows GET/HEAD requests only.
+	ScopeRead Scope = "read"
+	// ScopeWrite additionally allows submitting, cancelling and other
+	// mutating requests.
+	ScopeWrite Scope = "write"
+)
+
+// ParseScope validates s as a Scope.
+func ParseScope(s string) (Scope, error) {
+	switch Scope(s) {
+	case ScopeRead, ScopeWrite:
+		return Scope(s), nil
+	}
+	return "", fmt.Errorf("unknown scope %q (want read or write)", s)
+}
+
+// Allows reports whether a token with scope s may perform an action that
+// needs want.
+func (s Scope) Allows(want Scope) bool {
+	return s == ScopeWrite || s == want
+}
+
+// tokenPrefix marks Molecular tokens so they are recognisable in configs
+// and secret scanners.
+const tokenPrefix = "mol_"
+
+// Errors returned by Keyring.Authenticate.
+var (
+	ErrNoToken      = errors.New("missing bearer token")
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// Token is a stored token. Only the SHA-256 of the secret is kept.
+type Token struct {
+	ID        string `json:"id"`
+	Name      string `json:"name,omitempty"`
+	Scope     Scope  `json:"scope"`
+	Hash      string `json:"hash"`
+	CreatedAt string `json:"created_at"`
+}
+
+// TokensPath returns the token file inside a state directory.
+func TokensPath(stateDir string) string {
+	return filepath.Join(stateDir, "auth", "tokens.json")
+}
+
+// Hash returns the hex SHA-256 of a plaintext token.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewToken generates a token and returns its plaintext (shown to the user
+// exactly once) and the record to store.
+func NewToken(name string, scope Scope) (string, Token, error) {
+	var secret [32]byte
+	if _, err := rand.Read(secret[:]); err != nil {
+		return "", Token{}, err
+	}
+	var id [4]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", Token{}, err
+	}
+	plain := tokenPrefix + base64.RawURLEncoding.EncodeToString(secret[:])
+	return plain, Token{
+		ID:        hex.EncodeToString(id[:]),
+		Name:      name,
+		Scope:     scope,
+		Hash:      Hash(plain),
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// Load reads the tokens stored at path. A missing file yields no tokens.
+func Load(path string) ([]Token, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var toks []Token
+	if err := json.Unmarshal(b, &toks); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return toks, nil
+}
+
+// Add appends tok to the token file at path, creating it with owner-only
+// permissions.
+func Add(path string, tok Token) error {
+	toks, err := Load(path)
+	if err != nil {
+		return err
+	}
+	toks = append(toks, tok)
+	b, err := json.MarshalIndent(toks, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Keyring authenticates bearer tokens against a token file, reloading it
+// whenever it changes on disk.
+type Keyring struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	tokens  []Token
+}
+
+// NewKeyring returns a Keyring backed by the token file at path.
+func NewKeyring(path string) *Keyring {
+	return &Keyring{path: path}
+}
+
+// Authenticate returns the token matching the plaintext value.
+func (k *Keyring) Authenticate(plain string) (*Token, error) {
+	if plain == "" {
+		return nil, ErrNoToken
+	}
+	toks, err := k.load()
+	if err != nil {
+		return nil, err
+	}
+	h := []byte(Hash(plain))
+	for i := range toks {
+		if subtle.ConstantTimeCompare(h, []byte(toks[i].Hash)) == 1 {
+			t := toks[i]
+			return &t, nil
+		}
+	}
+	return nil, ErrInvalidToken
+}
+
+func (k *Keyring) load() ([]Token, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	fi, err := os.Stat(k.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		k.tokens, k.modTime, k.size = nil, time.Time{}, 0
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fi.ModTime().Equal(k.modTime) && fi.Size() == k.size && k.tokens != nil {
+		return k.tokens, nil
+	}
+	toks, err := Load(k.path)
+	if err != nil {
+		return nil, err
+	}
+	k.tokens, k.modTime, k.size = toks, fi.ModTime(), fi.Size()
+	return toks, nil
+}
+
+// BearerToken extracts the token from an Authorization header value.
+func BearerToken(header string) string {
+	scheme, tok, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(tok)
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTokenLifecycle(t *testing.T) {
+	path := TokensPath(t.TempDir())
+	k := NewKeyring(path)
+
+	if _, err := k.Authenticate("mol_anything"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken with no token file, got %v", err)
+	}
+
+	plain, tok, err := NewToken("ci", ScopeRead)
+	if err != nil {
+		t.Fatalf("new token: %v", err)
+	}
+	if !strings.HasPrefix(plain, "mol_") || tok.Hash == plain || tok.Hash != Hash(plain) {
+		t.Fatalf("unexpected token: %q %+v", plain, tok)
+	}
+	if err := Add(path, tok); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Fatalf("expected 0600 token file, got %v", fi.Mode().Perm())
+	}
+	b, _ := os.ReadFile(path)
+	if strings.Contains(string(b), plain) {
+		t.Fatalf("plaintext token stored on disk")
+	}
+
+	got, err := k.Authenticate(plain)
+	if err != nil || got.ID != tok.ID || got.Scope != ScopeRead {
+		t.Fatalf("authenticate: %+v %v", got, err)
+	}
+	if _, err := k.Authenticate(""); !errors.Is(err, ErrNoToken) {
+		t.Fatalf("expected ErrNoToken, got %v", err)
+	}
+
+	// tokens added later are picked up without a restart
+	plain2, tok2, _ := NewToken("bot", ScopeWrite)
+	if err := Add(path, tok2); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if got, err := k.Authenticate(plain2); err != nil || got.Scope != ScopeWrite {
+		t.Fatalf("authenticate second token: %+v %v", got, err)
+	}
+	if _, err := os.Stat(filepath.Dir(path)); err != nil {
+		t.Fatalf("auth dir: %v", err)
+	}
+}
+
+func TestScopesAndBearer(t *testing.T) {
+	if !ScopeWrite.Allows(ScopeRead) || !ScopeRead.Allows(ScopeRead) || ScopeRead.Allows(ScopeWrite) {
+		t.Fatalf("unexpected scope semantics")
+	}
+	if _, err := ParseScope("admin"); err == nil {
+		t.Fatalf("expected error for unknown scope")
+	}
+	if got := BearerToken("Bearer abc"); got != "abc" {
+		t.Fatalf("unexpected bearer token: %q", got)
+	}
+	if got := BearerToken("Basic abc"); got != "" {
+		t.Fatalf("expected empty token for basic auth, got %q", got)
+	}
+}
@@ -0,0 +1,281 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Path is where a repository keeps its configuration, relative to its top
+// level.
+const Path = ".molecular/config.toml"
+
+// Config is the part of .molecular/config.toml that Silicon reads. Other
+// tables in the file are left to other tools.
+type Config struct {
+	Limits Limits
+}
+
+// Limits caps what agents may consume. A zero field means no cap.
+type Limits struct {
+	// Task caps a single task; its duration counts from creation.
+	Task Caps
+	// Daily caps all tasks together over a UTC calendar day.
+	Daily Caps
+}
+
+// Caps are hard limits on tokens, dollars and wall-clock time.
+type Caps struct {
+	// MaxTokens bounds input plus output tokens.
+	MaxTokens   int64
+	MaxCostUSD  float64
+	MaxDuration time.Duration
+}
+
+// Load reads the configuration at path. A missing file yields the zero
+// Config.
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	cfg, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse reads a configuration in the subset of TOML it uses: [table]
+// headers and key = value lines with strings, integers, floats and
+// booleans. Unknown keys in Silicon's tables are errors, so a typo does
+// not silently lift a cap. Values in other tables are skipped unparsed,
+// so they may use any TOML, such as arrays, inline tables and multi-line
+// strings.
+func Parse(r io.Reader) (*Config, error) {
+	cfg := &Config{}
+	sc := bufio.NewScanner(r)
+	var table string
+	// foreign is a value outside Silicon's tables that spans lines
+	var foreign skipper
+	for n := 1; sc.Scan(); n++ {
+		if foreign.open() {
+			foreign.feed(sc.Text())
+			continue
+		}
+		line := strings.TrimSpace(stripComment(sc.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			name, ok := tableName(line)
+			if !ok {
+				return nil, fmt.Errorf("line %d: invalid table header %q", n, line)
+			}
+			if ours(name) && strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: [%s] is not an array of tables", n, name)
+			}
+			table = name
+			continue
+		}
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: want key = value, got %q", n, line)
+		}
+		if !ours(table) {
+			_, rest, _ := strings.Cut(sc.Text(), "=")
+			foreign.feed(rest)
+			continue
+		}
+		key, raw = strings.TrimSpace(key), strings.TrimSpace(raw)
+		v, err := parseValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", n, key, err)
+		}
+		if err := cfg.set(table, key, v); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// set assigns a value from table to the field it names.
+func (c *Config) set(table, key string, v any) error {
+	var caps *Caps
+	switch table {
+	case "limits.task":
+		caps = &c.Limits.Task
+	case "limits.daily":
+		caps = &c.Limits.Daily
+	default:
+		return fmt.Errorf("unknown table [%s]", table)
+	}
+	name := table + "." + key
+	switch key {
+	case "max_tokens":
+		n, ok := v.(int64)
+		if !ok || n < 0 {
+			return fmt.Errorf("%s: want a non-negative integer", name)
+		}
+		caps.MaxTokens = n
+	case "max_cost_usd":
+		f, ok := v.(float64)
+		if n, isInt := v.(int64); isInt {
+			f, ok = float64(n), true
+		}
+		if !ok || f < 0 {
+			return fmt.Errorf("%s: want a non-negative number", name)
+		}
+		caps.MaxCostUSD = f
+	case "max_duration":
+		s, ok := v.(string)
+		d, err := time.ParseDuration(s)
+		if !ok || err != nil || d < 0 {
+			return fmt.Errorf("%s: want a duration such as \"2h\"", name)
+		}
+		caps.MaxDuration = d
+	default:
+		return fmt.Errorf("unknown key %s", name)
+	}
+	return nil
+}
+
+// ours reports whether table is one of Silicon's.
+func ours(table string) bool {
+	return table == "limits" || strings.HasPrefix(table, "limits.")
+}
+
+// tableName returns the name in a [table] or [[array]] header.
+func tableName(line string) (string, bool) {
+	open, close := "[", "]"
+	if strings.HasPrefix(line, "[[") {
+		open, close = "[[", "]]"
+	}
+	if !strings.HasSuffix(line, close) || len(line) < len(open)+len(close) {
+		return "", false
+	}
+	name := strings.TrimSpace(line[len(open) : len(line)-len(close)])
+	return name, name != "" && !strings.ContainsAny(name, "[]")
+}
+
+// skipper follows a value it does not parse across lines, so a foreign
+// array, inline table or multi-line string is not mistaken for keys or
+// table headers.
+type skipper struct {
+	// depth counts open brackets and braces.
+	depth int
+	// quote is the delimiter of an open multi-line string.
+	quote string
+}
+
+func (k *skipper) open() bool { return k.depth > 0 || k.quote != "" }
+
+// feed scans the next piece of the value.
+func (k *skipper) feed(s string) {
+	for i := 0; i < len(s); i++ {
+		if k.quote != "" {
+			end := strings.Index(s[i:], k.quote)
+			if k.quote == `"""` {
+				end = closingQuote(s[i:], k.quote)
+			}
+			if end < 0 {
+				return
+			}
+			i += end + len(k.quote) - 1
+			k.quote = ""
+			continue
+		}
+		switch c := s[i]; c {
+		case '"', '\'':
+			q := string(c)
+			if strings.HasPrefix(s[i:], strings.Repeat(q, 3)) {
+				k.quote = strings.Repeat(q, 3)
+				i += 2
+				continue
+			}
+			end := strings.Index(s[i+1:], q)
+			if c == '"' {
+				end = closingQuote(s[i+1:], q)
+			}
+			if end < 0 {
+				return
+			}
+			i += end + 1
+		case '[', '{':
+			k.depth++
+		case ']', '}':
+			k.depth--
+		case '#':
+			return
+		}
+	}
+}
+
+// closingQuote is the index of the first q in s not escaped by a
+// backslash, or -1.
+func closingQuote(s, q string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(s[i:], q) {
+			return i
+		}
+	}
+	return -1
+}
+
+// stripComment drops a # comment that is not inside a string.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseValue parses a string, integer, float or boolean.
+func parseValue(raw string) (any, error) {
+	switch {
+	case raw == "true" || raw == "false":
+		return raw == "true", nil
+	case strings.HasPrefix(raw, `"`):
+		return strconv.Unquote(raw)
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") || strings.Contains(raw[1:len(raw)-1], "'") {
+			return nil, fmt.Errorf("invalid string %s", raw)
+		}
+		return raw[1 : len(raw)-1], nil
+	}
+	num := strings.ReplaceAll(raw, "_", "")
+	if n, err := strconv.ParseInt(num, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(num, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("unsupported value %s", raw)
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse(strings.NewReader(`
+# molecular settings
+[hooks]
+lithium = "scripts/setup.sh # not a comment"
+
+[limits.task]
+max_tokens = 2_000_000   # per task
+max_cost_usd = 5
+max_duration = "2h"
+
+[limits.daily]
+max_cost_usd = 50.5
+max_duration = '12h'
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := Limits{
+		Task:  Caps{MaxTokens: 2_000_000, MaxCostUSD: 5, MaxDuration: 2 * time.Hour},
+		Daily: Caps{MaxCostUSD: 50.5, MaxDuration: 12 * time.Hour},
+	}
+	if cfg.Limits != want {
+		t.Fatalf("got %+v, want %+v", cfg.Limits, want)
+	}
+
+	// other tools' tables may use any TOML
+	cfg, err = Parse(strings.NewReader(`
+owner = { name = "x" }
+
+[hooks]
+scripts = ["a", "b"]
+steps = [
+  "lint",   # ] not the end
+  ["nested", "]"],
+]
+env = { CI = "1", PATHS = ["/bin"] }
+notes = """
+[limits.task]
+max_tokens = 1 \"""
+"""
+raw = '''
+x = [
+'''
+
+[[plugins]]
+name = "p"
+
+[limits.task]
+max_tokens = 7
+`))
+	if err != nil {
+		t.Fatalf("parse with foreign tables: %v", err)
+	}
+	if want := (Limits{Task: Caps{MaxTokens: 7}}); cfg.Limits != want {
+		t.Fatalf("got %+v, want %+v", cfg.Limits, want)
+	}
+
+	for _, bad := range []string{
+		"[limits.task]\nmax_tokenz = 1",
+		"[limits.weekly]\nmax_tokens = 1",
+		"[limits.task]\nmax_tokens = -1",
+		"[limits.task]\nmax_tokens = 1.5",
+		"[limits.daily]\nmax_duration = 3",
+		"[limits.daily]\nmax_cost_usd = \"5\"",
+		"[limits.task\nmax_tokens = 1",
+		"[limits.task]\nmax_tokens",
+		"[limits.task]\nmax_tokens = [1]",
+		"[[limits.task]]\nmax_tokens = 1",
+		"[limits]\nmax_tokens = 1",
+	} {
+		if _, err := Parse(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := Load(filepath.Join(dir, "missing.toml"))
+	if err != nil || cfg.Limits != (Limits{}) {
+		t.Fatalf("missing file: %+v %v", cfg, err)
+	}
+	path := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(path, []byte("[limits.task]\nmax_tokens = x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), path) || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected error naming the file and line, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package devcerts
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerate_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	if err := Generate(dir, []string{"localhost", "127.0.0.1"}); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	fi, err := os.Stat(filepath.Join(dir, ServerKey))
+	if err != nil || fi.Mode().Perm() != 0o600 {
+		t.Fatalf("server key perms: %v %v", fi, err)
+	}
+
+	pool := x509.NewCertPool()
+	caPEM, _ := os.ReadFile(filepath.Join(dir, CACert))
+	if !pool.AppendCertsFromPEM(caPEM) {
+		t.Fatalf("bad CA PEM")
+	}
+	srvCert, err := tls.LoadX509KeyPair(filepath.Join(dir, ServerCert), filepath.Join(dir, ServerKey))
+	if err != nil {
+		t.Fatalf("load server pair: %v", err)
+	}
+	cliCert, err := tls.LoadX509KeyPair(filepath.Join(dir, ClientCert), filepath.Join(dir, ClientKey))
+	if err != nil {
+		t.Fatalf("load client pair: %v", err)
+	}
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	ts.TLS = &tls.Config{
+		Certificates: []tls.Certificate{srvCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{cliCert},
+	}}}
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("mTLS request: %v", err)
+	}
+	resp.Body.Close()
+
+	// without a client certificate the handshake is rejected
+	noCert := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	if resp, err := noCert.Get(ts.URL); err == nil {
+		resp.Body.Close()
+		t.Fatalf("expected handshake failure without client certificate")
+	}
+}
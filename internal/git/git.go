@@ -0,0 +1,100 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Error reports a failed git invocation together with its stderr.
+type Error struct {
+	Args   []string
+	Stderr string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	msg := strings.TrimSpace(e.Stderr)
+	if msg == "" {
+		msg = e.Err.Error()
+	}
+	return fmt.Sprintf("git %s: %s", strings.Join(e.Args, " "), msg)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// run executes git with args in dir and returns its stdout.
+func run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, &Error{Args: args, Stderr: stderr.String(), Err: err}
+	}
+	return stdout.Bytes(), nil
+}
+
+// Diff returns a unified diff of the working tree in dir against base.
+// When stat is true a diffstat summary is returned instead.
+func Diff(ctx context.Context, dir, base string, stat bool) ([]byte, error) {
+	args := []string{"diff", "--no-color", "--no-ext-diff"}
+	if stat {
+		args = append(args, "--stat")
+	}
+	args = append(args, base, "--")
+	return run(ctx, dir, args...)
+}
+
+// FormatPatch returns the commits in base..HEAD as an mbox stream that
+// `git am` can apply.
+func FormatPatch(ctx context.Context, dir, base string) ([]byte, error) {
+	return run(ctx, dir, "format-patch", "--stdout", "--no-color", base+"..HEAD")
+}
+
+// TopLevel returns the absolute path of the working tree containing dir.
+func TopLevel(ctx context.Context, dir string) (string, error) {
+	out, err := run(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ResolveCommit resolves ref to a full commit hash.
+func ResolveCommit(ctx context.Context, dir, ref string) (string, error) {
+	out, err := run(ctx, dir, "rev-parse", "--verify", "--quiet", "--end-of-options", ref+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// CheckBranchName reports whether name is a valid branch name.
+func CheckBranchName(ctx context.Context, dir, name string) error {
+	_, err := run(ctx, dir, "check-ref-format", "--branch", name)
+	return err
+}
+
+// BranchExists reports whether refs/heads/name exists in the repository.
+func BranchExists(ctx context.Context, dir, name string) bool {
+	_, err := run(ctx, dir, "show-ref", "--verify", "--quiet", "refs/heads/"+name)
+	return err == nil
+}
+
+// AddWorktree creates a new worktree at path on a new branch starting at
+// commit.
+func AddWorktree(ctx context.Context, repo, path, branch, commit string) error {
+	_, err := run(ctx, repo, "worktree", "add", "-q", "-b", branch, path, commit)
+	return err
+}
+
+// RemoveWorktree deletes the worktree at path, discarding uncommitted
+// changes. The branch is kept.
+func RemoveWorktree(ctx context.Context, repo, path string) error {
+	_, err := run(ctx, repo, "worktree", "remove", "--force", path)
+	return err
+}
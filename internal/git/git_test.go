@@ -0,0 +1,152 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// initRepo creates a repository with a single commit and returns its path
+// and the commit hash.
+func initRepo(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not in PATH")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "test"},
+		{"config", "commit.gpgsign", "false"},
+	} {
+		if _, err := run(context.Background(), dir, args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	commit(t, dir, "initial")
+	out, err := run(context.Background(), dir, "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatalf("rev-parse: %v", err)
+	}
+	return dir, strings.TrimSpace(string(out))
+}
+
+func commit(t *testing.T, dir, msg string) {
+	t.Helper()
+	if _, err := run(context.Background(), dir, "add", "-A"); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if _, err := run(context.Background(), dir, "commit", "-q", "-m", msg); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+}
+
+func TestDiffAndFormatPatch(t *testing.T) {
+	dir, base := initRepo(t)
+	ctx := context.Background()
+
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("two\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	commit(t, dir, "change a")
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("three\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := Diff(ctx, dir, base, false)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if !strings.Contains(string(d), "-one") || !strings.Contains(string(d), "+three") {
+		t.Fatalf("unexpected diff:\n%s", d)
+	}
+
+	st, err := Diff(ctx, dir, base, true)
+	if err != nil {
+		t.Fatalf("diff --stat: %v", err)
+	}
+	if !strings.Contains(string(st), "1 file changed") {
+		t.Fatalf("unexpected stat:\n%s", st)
+	}
+
+	p, err := FormatPatch(ctx, dir, base)
+	if err != nil {
+		t.Fatalf("format-patch: %v", err)
+	}
+	if !strings.Contains(string(p), "Subject: [PATCH] change a") || strings.Contains(string(p), "three") {
+		t.Fatalf("unexpected patch:\n%s", p)
+	}
+}
+
+func TestDiff_UnknownBase(t *testing.T) {
+	dir, _ := initRepo(t)
+	_, err := Diff(context.Background(), dir, "does-not-exist", false)
+	var gerr *Error
+	if !errors.As(err, &gerr) {
+		t.Fatalf("expected *Error, got %v", err)
+	}
+}
+
+func TestAddWorktree(t *testing.T) {
+	dir, base := initRepo(t)
+	ctx := context.Background()
+
+	top, err := TopLevel(ctx, dir)
+	if err != nil {
+		t.Fatalf("toplevel: %v", err)
+	}
+	if resolved, _ := filepath.EvalSymlinks(dir); top != resolved && top != dir {
+		t.Fatalf("unexpected toplevel %q", top)
+	}
+	if _, err := TopLevel(ctx, t.TempDir()); err == nil {
+		t.Fatalf("expected error outside a repository")
+	}
+
+	got, err := ResolveCommit(ctx, dir, "main")
+	if err != nil || got != base {
+		t.Fatalf("resolve main: %q, %v", got, err)
+	}
+	if _, err := ResolveCommit(ctx, dir, "release/9.9"); err == nil {
+		t.Fatalf("expected error for unknown ref")
+	}
+
+	if err := CheckBranchName(ctx, dir, "molecular/task-1"); err != nil {
+		t.Fatalf("check branch: %v", err)
+	}
+	if err := CheckBranchName(ctx, dir, "bad..name"); err == nil {
+		t.Fatalf("expected invalid branch name")
+	}
+
+	wt := filepath.Join(t.TempDir(), "wt")
+	if err := AddWorktree(ctx, dir, wt, "molecular/task-1", base); err != nil {
+		t.Fatalf("add worktree: %v", err)
+	}
+	if !BranchExists(ctx, dir, "molecular/task-1") {
+		t.Fatalf("expected branch to exist")
+	}
+	if _, err := os.Stat(filepath.Join(wt, "a.txt")); err != nil {
+		t.Fatalf("worktree not checked out: %v", err)
+	}
+
+	// removal discards local changes but keeps the branch
+	if err := os.WriteFile(filepath.Join(wt, "a.txt"), []byte("dirty\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveWorktree(ctx, dir, wt); err != nil {
+		t.Fatalf("remove worktree: %v", err)
+	}
+	if _, err := os.Stat(wt); !os.IsNotExist(err) {
+		t.Fatalf("worktree still present: %v", err)
+	}
+	if !BranchExists(ctx, dir, "molecular/task-1") {
+		t.Fatalf("expected branch to survive removal")
+	}
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_TextFormat(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("jobs_total", "Jobs by result.\nMultiline.", "result")
+	h := r.Histogram("job_seconds", "Job duration.", []float64{1, 5}, "kind")
+	r.GaugeFunc("queue_depth", "Queued jobs.", nil, func() []Sample { return []Sample{{Value: 3}} })
+	r.GaugeFunc("jobs", "Jobs by state.", []string{"state"}, func() []Sample {
+		return []Sample{{[]string{"running"}, 2}, {[]string{`a"b\c`}, 1}}
+	})
+
+	c.Inc("ok")
+	c.Add(2.5, "ok")
+	c.Inc("failed")
+	h.Observe(0.5, "build")
+	h.Observe(1, "build")
+	h.Observe(7, "build")
+
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP jobs_total Jobs by result.\nMultiline.
+# TYPE jobs_total counter
+jobs_total{result="failed"} 1
+jobs_total{result="ok"} 3.5
+# HELP job_seconds Job duration.
+# TYPE job_seconds histogram
+job_seconds_bucket{kind="build",le="1"} 2
+job_seconds_bucket{kind="build",le="5"} 2
+job_seconds_bucket{kind="build",le="+Inf"} 3
+job_seconds_sum{kind="build"} 8.5
+job_seconds_count{kind="build"} 3
+# HELP queue_depth Queued jobs.
+# TYPE queue_depth gauge
+queue_depth 3
+# HELP jobs Jobs by state.
+# TYPE jobs gauge
+jobs{state="a\"b\\c"} 1
+jobs{state="running"} 2
+`
+	if b.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Header().Get("Content-Type") != ContentType || rec.Body.String() != want {
+		t.Fatalf("handler: %q\n%s", rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+}
+
+func TestRegistry_Misuse(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("c_total", "c", "a", "b")
+	for name, f := range map[string]func(){
+		"label count":      func() { c.Inc("only-one") },
+		"negative add":     func() { c.Add(-1, "x", "y") },
+		"unsorted buckets": func() { r.Histogram("h", "h", []float64{2, 1}) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
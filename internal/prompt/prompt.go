@@ -0,0 +1,234 @@
+package prompt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// Roles with a built-in template.
+const (
+	RoleCarbon = "carbon"
+	RoleHelium = "helium"
+)
+
+// Dir is where a repository overrides the built-in templates, as
+// <role>.tmpl relative to its top level.
+const Dir = ".molecular/prompts"
+
+// DefaultMaxBytes bounds a rendered prompt when no limit is given.
+const DefaultMaxBytes = 64 << 10
+
+// OriginBuiltin is the Origin of templates compiled into Silicon.
+const OriginBuiltin = "builtin"
+
+// ErrTooLarge is returned when a prompt cannot be shrunk to fit its limit.
+var ErrTooLarge = errors.New("prompt exceeds size limit")
+
+//go:embed templates/*.tmpl
+var builtin embed.FS
+
+// Repo is the repository context of a task with a worktree.
+type Repo struct {
+	Path       string `json:"path"`
+	BaseRef    string `json:"base_ref,omitempty"`
+	BaseCommit string `json:"base_commit,omitempty"`
+	Branch     string `json:"branch,omitempty"`
+}
+
+// Input is the data a template renders.
+type Input struct {
+	TaskID string `json:"task_id"`
+	// Attempt is the attempt number within the task, from 1.
+	Attempt int64  `json:"attempt"`
+	Prompt  string `json:"prompt"`
+	Repo    *Repo  `json:"repo,omitempty"`
+	// Issues are the review issues raised on the previous attempt.
+	Issues []string `json:"issues,omitempty"`
+	// Feedback is the human feedback to act on, oldest first.
+	Feedback []molecular.Feedback `json:"feedback,omitempty"`
+	// OmittedIssues and OmittedFeedback count the oldest entries dropped
+	// to fit the size limit.
+	OmittedIssues   int `json:"omitted_issues,omitempty"`
+	OmittedFeedback int `json:"omitted_feedback,omitempty"`
+}
+
+// Template is a role's prompt template and where it came from.
+type Template struct {
+	Role string `json:"role"`
+	// Origin is OriginBuiltin or the path of the override.
+	Origin string `json:"origin"`
+	Source string `json:"source"`
+	SHA256 string `json:"sha256"`
+
+	tmpl *template.Template
+}
+
+// Load returns the template for role: the override in repoDir's Dir when
+// there is one, else the built-in template. repoDir may be empty.
+func Load(role, repoDir string) (*Template, error) {
+	if repoDir != "" {
+		path := filepath.Join(repoDir, filepath.FromSlash(Dir), role+".tmpl")
+		b, err := os.ReadFile(path)
+		if err == nil {
+			return Parse(role, path, string(b))
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	b, err := builtin.ReadFile("templates/" + role + ".tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("no prompt template for role %q", role)
+	}
+	return Parse(role, OriginBuiltin, string(b))
+}
+
+// Parse parses a template source, as recorded in Rendered.Template, so a
+// prompt can be rendered again.
+func Parse(role, origin, source string) (*Template, error) {
+	tmpl, err := template.New(role).Option("missingkey=error").Funcs(funcs).Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("prompt template %s: %w", origin, err)
+	}
+	sum := sha256.Sum256([]byte(source))
+	return &Template{Role: role, Origin: origin, Source: source, SHA256: hex.EncodeToString(sum[:]), tmpl: tmpl}, nil
+}
+
+var funcs = template.FuncMap{
+	"trim": strings.TrimSpace,
+	// indent prefixes every line but the first with n spaces, so
+	// multi-line text stays inside a list item.
+	"indent": func(n int, s string) string {
+		return strings.ReplaceAll(strings.TrimSpace(s), "\n", "\n"+strings.Repeat(" ", n))
+	},
+}
+
+// Rendered is a rendered prompt with everything needed to render it again:
+// the template and the input after truncation.
+type Rendered struct {
+	Text     string   `json:"-"`
+	SHA256   string   `json:"sha256"`
+	Bytes    int      `json:"bytes"`
+	MaxBytes int      `json:"max_bytes"`
+	Template Template `json:"template"`
+	Input    Input    `json:"input"`
+	// Truncated describes each step taken to fit the size limit.
+	Truncated []string `json:"truncated,omitempty"`
+}
+
+// Render renders in, shrinking it to fit within maxBytes (DefaultMaxBytes
+// when zero). Rendering is deterministic. When the prompt is too large,
+// Render drops the oldest review issues, then the oldest feedback, and
+// finally cuts the middle out of the task prompt, keeping its head and
+// tail. ErrTooLarge is returned if even that is not enough.
+func (t *Template) Render(in Input, maxBytes int) (*Rendered, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	r := &Rendered{MaxBytes: maxBytes, Template: *t}
+	r.Template.tmpl = nil
+
+	text, err := t.execute(in)
+	if err != nil {
+		return nil, err
+	}
+	if len(text) > maxBytes && len(in.Issues) > 0 {
+		var n int
+		if text, n, err = t.dropOldest(&in, maxBytes, func(in *Input) bool {
+			if len(in.Issues) == 0 {
+				return false
+			}
+			in.Issues = in.Issues[1:]
+			in.OmittedIssues++
+			return true
+		}); err != nil {
+			return nil, err
+		}
+		r.Truncated = append(r.Truncated, fmt.Sprintf("dropped %d oldest review issue(s)", n))
+	}
+	if len(text) > maxBytes && len(in.Feedback) > 0 {
+		var n int
+		if text, n, err = t.dropOldest(&in, maxBytes, func(in *Input) bool {
+			if len(in.Feedback) == 0 {
+				return false
+			}
+			in.Feedback = in.Feedback[1:]
+			in.OmittedFeedback++
+			return true
+		}); err != nil {
+			return nil, err
+		}
+		r.Truncated = append(r.Truncated, fmt.Sprintf("dropped %d oldest feedback note(s)", n))
+	}
+	if len(text) > maxBytes {
+		orig := len(in.Prompt)
+		for len(text) > maxBytes && in.Prompt != "" {
+			in.Prompt = cutMiddle(in.Prompt, len(text)-maxBytes)
+			if text, err = t.execute(in); err != nil {
+				return nil, err
+			}
+		}
+		r.Truncated = append(r.Truncated, fmt.Sprintf("cut the task prompt from %d to %d bytes", orig, len(in.Prompt)))
+	}
+	if len(text) > maxBytes {
+		return nil, fmt.Errorf("%w: %d bytes over %d even with an empty prompt", ErrTooLarge, len(text), maxBytes)
+	}
+
+	sum := sha256.Sum256([]byte(text))
+	r.Text, r.SHA256, r.Bytes, r.Input = text, hex.EncodeToString(sum[:]), len(text), in
+	return r, nil
+}
+
+func (t *Template) execute(in Input) (string, error) {
+	var b bytes.Buffer
+	if err := t.tmpl.Execute(&b, in); err != nil {
+		return "", fmt.Errorf("rendering %s prompt: %w", t.Role, err)
+	}
+	return b.String(), nil
+}
+
+// dropOldest applies drop to in until the prompt fits or drop has nothing
+// left to remove. It returns the last rendering and how many entries went.
+func (t *Template) dropOldest(in *Input, maxBytes int, drop func(*Input) bool) (string, int, error) {
+	var n int
+	for drop(in) {
+		n++
+		text, err := t.execute(*in)
+		if err != nil || len(text) <= maxBytes {
+			return text, n, err
+		}
+	}
+	text, err := t.execute(*in)
+	return text, n, err
+}
+
+// cutMiddle removes at least over bytes from the middle of s, replacing
+// them with a marker, and keeps the head and tail on rune boundaries.
+func cutMiddle(s string, over int) string {
+	const marker = "\n\n[... %d bytes omitted ...]\n\n"
+	cut := over + len(fmt.Sprintf(marker, len(s)))
+	if cut >= len(s) {
+		return ""
+	}
+	head := (len(s) - cut) / 2
+	tail := len(s) - (len(s) - cut - head)
+	for head > 0 && !utf8.RuneStart(s[head]) {
+		head--
+	}
+	for tail < len(s) && !utf8.RuneStart(s[tail]) {
+		tail++
+	}
+	return s[:head] + fmt.Sprintf(marker, tail-head) + s[tail:]
+}
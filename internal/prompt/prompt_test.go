@@ -0,0 +1,171 @@
+package prompt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+func TestRender_Builtin(t *testing.T) {
+	tmpl, err := Load(RoleCarbon, t.TempDir())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if tmpl.Origin != OriginBuiltin || len(tmpl.SHA256) != 64 {
+		t.Fatalf("unexpected template: %+v", tmpl)
+	}
+	in := Input{
+		TaskID:   "t1",
+		Attempt:  2,
+		Prompt:   "fix the login bug\n",
+		Repo:     &Repo{Path: "/src/app", BaseRef: "main", BaseCommit: "0123abcd", Branch: "molecular/t1"},
+		Issues:   []string{"the redirect\nloops", "no test"},
+		Feedback: []molecular.Feedback{{Message: "keep the v1 endpoint", Author: "ana"}, {Message: "use the new client"}},
+	}
+	r, err := tmpl.Render(in, 0)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	want := `You are Carbon, the agent that implements task t1. This is attempt 2.
+
+Work in the git worktree you were started in, on branch molecular/t1.
+It starts from main (0123abcd) in /src/app.
+Commit your changes on that branch.
+
+## Task
+
+fix the login bug
+
+## Review issues
+
+The previous attempt was reviewed. Address every issue below.
+
+- the redirect
+  loops
+- no test
+
+## Reviewer feedback
+
+A human reviewer added these notes while you worked. Follow them.
+
+- ana: keep the v1 endpoint
+- use the new client
+`
+	if r.Text != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", r.Text, want)
+	}
+	if r.Bytes != len(want) || r.MaxBytes != DefaultMaxBytes || r.Truncated != nil {
+		t.Fatalf("unexpected metadata: %+v", r)
+	}
+	if again, _ := tmpl.Render(in, 0); again.Text != r.Text || again.SHA256 != r.SHA256 {
+		t.Fatalf("rendering is not deterministic")
+	}
+
+	helium, err := Load(RoleHelium, "")
+	if err != nil {
+		t.Fatalf("load helium: %v", err)
+	}
+	r, err = helium.Render(Input{TaskID: "t1", Attempt: 3, Prompt: "fix the login bug"}, 0)
+	if err != nil || !strings.HasPrefix(r.Text, "You are Helium") || strings.Contains(r.Text, "Review issues") {
+		t.Fatalf("helium: %v\n%s", err, r.Text)
+	}
+
+	if _, err := Load("neon", ""); err == nil {
+		t.Fatalf("expected error for a role without a template")
+	}
+}
+
+func TestLoad_Override(t *testing.T) {
+	repo := t.TempDir()
+	dir := filepath.Join(repo, filepath.FromSlash(Dir))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "carbon.tmpl")
+	if err := os.WriteFile(path, []byte("{{.TaskID}}: {{.Prompt}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl, err := Load(RoleCarbon, repo)
+	if err != nil || tmpl.Origin != path {
+		t.Fatalf("load: %+v %v", tmpl, err)
+	}
+	if r, err := tmpl.Render(Input{TaskID: "t1", Prompt: "p"}, 0); err != nil || r.Text != "t1: p\n" {
+		t.Fatalf("render: %q %v", r.Text, err)
+	}
+	// helium has no override and falls back to the built-in template
+	if tmpl, err := Load(RoleHelium, repo); err != nil || tmpl.Origin != OriginBuiltin {
+		t.Fatalf("helium: %+v %v", tmpl, err)
+	}
+
+	// unknown fields fail instead of rendering "<no value>"
+	if err := os.WriteFile(path, []byte("{{.Ticket}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, _ = Load(RoleCarbon, repo)
+	if _, err := tmpl.Render(Input{}, 0); err == nil {
+		t.Fatalf("expected error for unknown field")
+	}
+	if err := os.WriteFile(path, []byte("{{.Prompt"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(RoleCarbon, repo); err == nil || !strings.Contains(err.Error(), path) {
+		t.Fatalf("expected parse error naming the override, got %v", err)
+	}
+}
+
+func TestRender_Truncation(t *testing.T) {
+	tmpl, err := Load(RoleCarbon, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := Input{
+		TaskID:   "t1",
+		Attempt:  4,
+		Prompt:   "HEAD " + strings.Repeat("é", 2000) + " TAIL",
+		Issues:   []string{strings.Repeat("old issue ", 50), "newest issue"},
+		Feedback: []molecular.Feedback{{Message: strings.Repeat("old note ", 50)}, {Message: "newest note"}},
+	}
+	full, _ := tmpl.Render(in, 0)
+
+	// dropping the oldest issue is enough
+	r, err := tmpl.Render(in, full.Bytes-100)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if r.Input.OmittedIssues != 1 || len(r.Input.Feedback) != 2 || len(r.Truncated) != 1 || !strings.Contains(r.Text, "(1 older issue(s) omitted for length.)") {
+		t.Fatalf("expected only the oldest issue dropped: %+v\n%s", r.Truncated, r.Text)
+	}
+
+	// a tight limit drops issues and feedback, then cuts the prompt
+	r, err = tmpl.Render(in, 2500)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if r.Bytes > 2500 || len(r.Truncated) != 3 || len(r.Input.Issues) != 0 || r.Input.OmittedFeedback != 2 {
+		t.Fatalf("unexpected truncation: %d bytes, %v", r.Bytes, r.Truncated)
+	}
+	if !strings.Contains(r.Text, "HEAD ") || !strings.Contains(r.Text, " TAIL") || !strings.Contains(r.Text, "bytes omitted ...]") {
+		t.Fatalf("expected head and tail around the cut:\n%s", r.Text)
+	}
+	if !strings.Contains(r.Text, "é") || strings.Contains(r.Text, "�") {
+		t.Fatalf("cut split a rune")
+	}
+
+	// the record is enough to render the same prompt again
+	replay, err := Parse(r.Template.Role, r.Template.Origin, r.Template.Source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := replay.Render(r.Input, r.MaxBytes); err != nil || again.SHA256 != r.SHA256 {
+		t.Fatalf("replay differs: %v", err)
+	}
+
+	if _, err := tmpl.Render(in, 50); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+}
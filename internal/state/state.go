@@ -0,0 +1,30 @@
+package state
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// EnvDir names the environment variable that overrides the state directory.
+const EnvDir = "MOLECULAR_STATE_DIR"
+
+// Dir returns the directory Silicon keeps its on-disk state in. It honours
+// MOLECULAR_STATE_DIR, then $XDG_STATE_HOME/molecular, and finally falls back
+// to ~/.local/state/molecular. The directory is not created.
+func Dir() (string, error) {
+	if d := os.Getenv(EnvDir); d != "" {
+		return filepath.Abs(d)
+	}
+	if d := os.Getenv("XDG_STATE_HOME"); d != "" {
+		return filepath.Join(d, "molecular"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if home == "" {
+		return "", errors.New("cannot determine home directory")
+	}
+	return filepath.Join(home, ".local", "state", "molecular"), nil
+}
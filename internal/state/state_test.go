@@ -0,0 +1,39 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDir_Precedence(t *testing.T) {
+	tmp := t.TempDir()
+
+	t.Setenv(EnvDir, filepath.Join(tmp, "explicit"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(tmp, "xdg"))
+	got, err := Dir()
+	if err != nil {
+		t.Fatalf("dir: %v", err)
+	}
+	if got != filepath.Join(tmp, "explicit") {
+		t.Fatalf("expected explicit dir, got %q", got)
+	}
+
+	t.Setenv(EnvDir, "")
+	got, err = Dir()
+	if err != nil {
+		t.Fatalf("dir: %v", err)
+	}
+	if got != filepath.Join(tmp, "xdg", "molecular") {
+		t.Fatalf("expected xdg dir, got %q", got)
+	}
+
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("HOME", tmp)
+	got, err = Dir()
+	if err != nil {
+		t.Fatalf("dir: %v", err)
+	}
+	if got != filepath.Join(tmp, ".local", "state", "molecular") {
+		t.Fatalf("expected home fallback, got %q", got)
+	}
+}
@@ -0,0 +1,193 @@
+package task
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// Environment variables an agent is run with, besides Silicon's own.
+const (
+	EnvTaskID     = "MOLECULAR_TASK_ID"
+	EnvAttempt    = "MOLECULAR_ATTEMPT"
+	EnvRole       = "MOLECULAR_ROLE"
+	EnvAttemptDir = "MOLECULAR_ATTEMPT_DIR"
+	// EnvUsageFile is where the agent may write its UsageFile report.
+	EnvUsageFile = "MOLECULAR_USAGE_FILE"
+)
+
+// Files in the attempt's logs directory that capture the agent's output.
+const (
+	AgentStdout = "agent.stdout.log"
+	AgentStderr = "agent.stderr.log"
+)
+
+// Runner runs the agent of an attempt. Command is a shell command line run
+// with the attempt's rendered prompt on stdin, in the task's worktree, else
+// its repository, else the attempt's directory. An empty Command runs no
+// agent.
+//
+// The agent reports its usage by writing UsageFile, or else by printing it
+// on stdout as a JSON line: either in UsageFile's format or as a result
+// object with a "usage" object of input_tokens and output_tokens and a
+// "total_cost_usd". The last such line wins, and the runner writes it to
+// UsageFile.
+type Runner struct {
+	Command string
+}
+
+// waitDelay bounds how long a cancelled agent's children may hold its
+// output open.
+const waitDelay = 5 * time.Second
+
+// Run runs the agent for t's latest attempt and waits for it to exit.
+func (r Runner) Run(ctx context.Context, t molecular.Task) error {
+	a := t.LatestAttempt
+	if r.Command == "" || a == nil || a.ArtifactsDir == "" {
+		return nil
+	}
+	prompt, err := os.Open(filepath.Join(a.ArtifactsDir, artifacts.DirPrompts, a.Role+".md"))
+	if err != nil {
+		return fmt.Errorf("agent prompt: %w", err)
+	}
+	defer prompt.Close()
+	logs := filepath.Join(a.ArtifactsDir, artifacts.DirLogs)
+	stdout, err := os.Create(filepath.Join(logs, AgentStdout))
+	if err != nil {
+		return fmt.Errorf("agent output: %w", err)
+	}
+	defer stdout.Close()
+	stderr, err := os.Create(filepath.Join(logs, AgentStderr))
+	if err != nil {
+		return fmt.Errorf("agent output: %w", err)
+	}
+	defer stderr.Close()
+
+	usageFile := filepath.Join(a.ArtifactsDir, artifacts.DirResults, UsageFile)
+	cmd := exec.CommandContext(ctx, "sh", "-c", r.Command)
+	cmd.Dir = workDir(t)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = prompt, stdout, stderr
+	cmd.Env = append(os.Environ(),
+		EnvTaskID+"="+t.TaskID,
+		EnvAttempt+"="+strconv.FormatInt(a.AttemptNum, 10),
+		EnvRole+"="+a.Role,
+		EnvAttemptDir+"="+a.ArtifactsDir,
+		EnvUsageFile+"="+usageFile,
+	)
+	cmd.WaitDelay = waitDelay
+	runErr := cmd.Run()
+
+	// an agent that stopped early may still have reported what it used
+	if err := writeReportedUsage(stdout.Name(), usageFile); err != nil && runErr == nil {
+		runErr = err
+	}
+	if runErr != nil {
+		return fmt.Errorf("agent: %w", runErr)
+	}
+	return nil
+}
+
+// workDir is where the agent runs.
+func workDir(t molecular.Task) string {
+	switch {
+	case t.WorktreePath != "":
+		return t.WorktreePath
+	case t.RepoPath != "":
+		return t.RepoPath
+	}
+	return t.LatestAttempt.ArtifactsDir
+}
+
+// writeReportedUsage writes the last usage line of the agent's stdout to
+// usageFile, unless the agent wrote usageFile itself.
+func writeReportedUsage(stdout, usageFile string) error {
+	if _, err := os.Stat(usageFile); !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	f, err := os.Open(stdout)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var last *molecular.Usage
+	br := bufio.NewReader(f)
+	for {
+		line, err := br.ReadBytes('\n')
+		if u := parseUsageLine(line); u != nil {
+			last = u
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if last == nil {
+		return nil
+	}
+	b, err := json.Marshal(last)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(usageFile, append(b, '\n'), 0o644)
+}
+
+// parseUsageLine returns the usage a line of agent output reports, or nil.
+func parseUsageLine(line []byte) *molecular.Usage {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return nil
+	}
+	var v struct {
+		Model        string   `json:"model"`
+		InputTokens  *int64   `json:"input_tokens"`
+		OutputTokens *int64   `json:"output_tokens"`
+		CostUSD      *float64 `json:"cost_usd"`
+		TotalCostUSD *float64 `json:"total_cost_usd"`
+		Usage        *struct {
+			InputTokens  int64 `json:"input_tokens"`
+			OutputTokens int64 `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if json.Unmarshal(line, &v) != nil {
+		return nil
+	}
+	u := molecular.Usage{Model: v.Model}
+	switch {
+	case v.Usage != nil:
+		u.InputTokens, u.OutputTokens = v.Usage.InputTokens, v.Usage.OutputTokens
+	case v.InputTokens != nil || v.OutputTokens != nil:
+		if v.InputTokens != nil {
+			u.InputTokens = *v.InputTokens
+		}
+		if v.OutputTokens != nil {
+			u.OutputTokens = *v.OutputTokens
+		}
+	default:
+		return nil
+	}
+	switch {
+	case v.CostUSD != nil:
+		u.CostUSD = *v.CostUSD
+	case v.TotalCostUSD != nil:
+		u.CostUSD = *v.TotalCostUSD
+	}
+	if u.InputTokens < 0 || u.OutputTokens < 0 || u.CostUSD < 0 {
+		return nil
+	}
+	return &u
+}
@@ -0,0 +1,87 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// attemptDir lays out an attempt with a rendered Carbon prompt.
+func attemptDir(t *testing.T) *molecular.Attempt {
+	t.Helper()
+	dir := t.TempDir()
+	for _, sub := range []string{artifacts.DirPrompts, artifacts.DirResults, artifacts.DirLogs} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, artifacts.DirPrompts, "carbon.md"), []byte("fix the bug\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return &molecular.Attempt{Role: "carbon", AttemptNum: 1, ArtifactsDir: dir}
+}
+
+func TestRunner_UsageFromOutput(t *testing.T) {
+	a := attemptDir(t)
+	task := molecular.Task{TaskID: "task-1", LatestAttempt: a}
+	// the agent reads its prompt and prints a result object last
+	r := Runner{Command: `read -r p; echo "working on: $p"; echo '{"usage":{"input_tokens":10,"output_tokens":5}}'; ` +
+		`echo '{"type":"result","model":"m1","usage":{"input_tokens":1200,"output_tokens":300},"total_cost_usd":0.25}'`}
+	u, err := Execute(context.Background(), task, r)
+	if err != nil || u == nil || *u != (molecular.Usage{Model: "m1", InputTokens: 1200, OutputTokens: 300, CostUSD: 0.25}) {
+		t.Fatalf("execute: %+v %v", u, err)
+	}
+	b, err := os.ReadFile(filepath.Join(a.ArtifactsDir, artifacts.DirResults, UsageFile))
+	if err != nil {
+		t.Fatalf("usage file not written: %v", err)
+	}
+	var written molecular.Usage
+	if err := json.Unmarshal(b, &written); err != nil || written != *u {
+		t.Fatalf("usage file: %s %v", b, err)
+	}
+	out, _ := os.ReadFile(filepath.Join(a.ArtifactsDir, artifacts.DirLogs, AgentStdout))
+	if !strings.HasPrefix(string(out), "working on: fix the bug\n") {
+		t.Fatalf("agent stdout: %q", out)
+	}
+}
+
+func TestRunner_UsageFileWins(t *testing.T) {
+	a := attemptDir(t)
+	task := molecular.Task{TaskID: "task-1", LatestAttempt: a}
+	r := Runner{Command: `echo '{"input_tokens":1,"output_tokens":1}'; ` +
+		`echo '{"model":"m2","input_tokens":7,"output_tokens":3,"cost_usd":0.01}' > "$` + EnvUsageFile + `"; ` +
+		`test "$` + EnvTaskID + `" = task-1 && test "$` + EnvRole + `" = carbon && test "$` + EnvAttempt + `" = 1 && test "$` + EnvAttemptDir + `" = "$PWD"`}
+	u, err := Execute(context.Background(), task, r)
+	if err != nil || u == nil || *u != (molecular.Usage{Model: "m2", InputTokens: 7, OutputTokens: 3, CostUSD: 0.01}) {
+		t.Fatalf("execute: %+v %v", u, err)
+	}
+}
+
+func TestRunner_Failure(t *testing.T) {
+	a := attemptDir(t)
+	task := molecular.Task{TaskID: "task-1", LatestAttempt: a}
+	// usage is kept even when the agent fails
+	r := Runner{Command: `echo '{"input_tokens":4,"output_tokens":2}'; echo boom >&2; exit 3`}
+	u, err := Execute(context.Background(), task, r)
+	if err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Fatalf("expected the exit status, got %v", err)
+	}
+	if u == nil || u.InputTokens != 4 {
+		t.Fatalf("usage of the failed attempt: %+v", u)
+	}
+	errOut, _ := os.ReadFile(filepath.Join(a.ArtifactsDir, artifacts.DirLogs, AgentStderr))
+	if string(errOut) != "boom\n" {
+		t.Fatalf("agent stderr: %q", errOut)
+	}
+
+	// no command runs no agent
+	if u, err := Execute(context.Background(), molecular.Task{TaskID: "t2", LatestAttempt: attemptDir(t)}, Runner{}); u != nil || err != nil {
+		t.Fatalf("without an agent: %+v %v", u, err)
+	}
+}
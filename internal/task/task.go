@@ -2,9 +2,16 @@ package task
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
 
-	"github.com/throw-if-null/molecular/internal/api"
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/pkg/molecular"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -14,14 +21,17 @@ import (
 // Execute runs a single task lifecycle, emitting tracing spans and events.
 // This is intentionally small: it creates a root span for the task and
 // emits events for key state transitions so tests and tracing backends can
-// observe task progress.
-func Execute(ctx context.Context, t api.Task) error {
+// observe task progress. The attempt runs its agent with r. It returns the
+// usage the agent reported, or nil, which is recorded on the spans either
+// way the attempt ends.
+func Execute(ctx context.Context, t molecular.Task, r Runner) (*molecular.Usage, error) {
 	tr := otel.Tracer("silicon")
+	attrs := append([]attribute.KeyValue{attribute.String("task.id", t.TaskID)}, labelAttributes(t.Labels)...)
 	ctx, span := tr.Start(
 		ctx,
 		"silicon.task",
 		trace.WithNewRoot(),
-		trace.WithAttributes(attribute.String("task.id", t.TaskID)),
+		trace.WithAttributes(attrs...),
 	)
 	defer span.End()
 
@@ -32,8 +42,15 @@ func Execute(ctx context.Context, t api.Task) error {
 	span.AddEvent("task.started")
 
 	// child operation (attempt) to exercise context propagation
-	_, child := tr.Start(ctx, "silicon.attempt", trace.WithAttributes(attribute.String("task.id", t.TaskID)))
+	_, child := tr.Start(ctx, "silicon.attempt", trace.WithAttributes(attrs...))
 	child.AddEvent("attempt.started")
+	if a := t.LatestAttempt; a != nil && len(a.Feedback) > 0 {
+		// notes included in this attempt's prompt; the decision.human_feedback
+		// events are on the requests that added them
+		child.SetAttributes(attribute.Int("silicon.attempt.feedback_notes", len(a.Feedback)))
+	}
+	runErr := r.Run(ctx, t)
+	usage := recordUsage(span, child, t)
 	select {
 	case <-ctx.Done():
 		err := ctx.Err()
@@ -48,9 +65,20 @@ func Execute(ctx context.Context, t api.Task) error {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		span.AddEvent("task.cancelled")
-		return err
+		return usage, err
 	default:
 	}
+	if runErr != nil {
+		child.RecordError(runErr)
+		child.SetStatus(codes.Error, runErr.Error())
+		child.AddEvent("attempt.failed")
+		child.End()
+
+		span.RecordError(runErr)
+		span.SetStatus(codes.Error, runErr.Error())
+		span.AddEvent("task.failed")
+		return usage, runErr
+	}
 
 	child.AddEvent("attempt.completed")
 	child.End()
@@ -58,5 +86,80 @@ func Execute(ctx context.Context, t api.Task) error {
 	// task completed
 	span.AddEvent("task.completed")
 	span.SetStatus(codes.Ok, "")
-	return nil
+	return usage, nil
+}
+
+// UsageFile is the sidecar in an attempt's results directory that reports
+// what the attempt consumed, written by the agent or by Runner from the
+// agent's output:
+//
+//	{"model": "...", "input_tokens": 1200, "output_tokens": 300, "cost_usd": 0.0123}
+const UsageFile = "usage.json"
+
+// ReadUsage reads the usage reported in an attempt's results directory. It
+// returns nil when the runner reported none.
+func ReadUsage(attemptDir string) (*molecular.Usage, error) {
+	b, err := os.ReadFile(filepath.Join(attemptDir, artifacts.DirResults, UsageFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var u molecular.Usage
+	if err := json.Unmarshal(b, &u); err != nil {
+		return nil, fmt.Errorf("%s: %w", UsageFile, err)
+	}
+	if u.InputTokens < 0 || u.OutputTokens < 0 || u.CostUSD < 0 {
+		return nil, fmt.Errorf("%s: negative usage", UsageFile)
+	}
+	return &u, nil
+}
+
+// recordUsage reads the usage of t's latest attempt and sets it on the
+// attempt span, and the task's running totals on the task span. A bad
+// report is recorded as an error on the attempt span and ignored.
+func recordUsage(span, child trace.Span, t molecular.Task) *molecular.Usage {
+	a := t.LatestAttempt
+	if a == nil || a.ArtifactsDir == "" {
+		return nil
+	}
+	u, err := ReadUsage(a.ArtifactsDir)
+	if err != nil {
+		child.RecordError(err)
+		return nil
+	}
+	if u == nil {
+		return nil
+	}
+	child.SetAttributes(append(usageAttributes(*u), attribute.String("silicon.usage.model", u.Model))...)
+	total := *u
+	if t.Usage != nil {
+		total.Add(*t.Usage)
+	}
+	span.SetAttributes(usageAttributes(total)...)
+	return u
+}
+
+func usageAttributes(u molecular.Usage) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Int64("silicon.usage.input_tokens", u.InputTokens),
+		attribute.Int64("silicon.usage.output_tokens", u.OutputTokens),
+		attribute.Float64("silicon.usage.cost_usd", u.CostUSD),
+	}
+}
+
+// labelAttributes turns task labels into silicon.label.<key> span
+// attributes, sorted by key so spans are deterministic.
+func labelAttributes(labels map[string]string) []attribute.KeyValue {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]attribute.KeyValue, len(keys))
+	for i, k := range keys {
+		attrs[i] = attribute.String("silicon.label."+k, labels[k])
+	}
+	return attrs
 }
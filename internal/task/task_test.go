@@ -2,9 +2,12 @@ package task
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
-	"github.com/throw-if-null/molecular/internal/api"
+	"github.com/throw-if-null/molecular/internal/artifacts"
+	"github.com/throw-if-null/molecular/pkg/molecular"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	sdkresource "go.opentelemetry.io/otel/sdk/resource"
@@ -35,10 +38,10 @@ func TestExecute_EmitsSpans(t *testing.T) {
 	}()
 
 	// create a test task that succeeds
-	task := api.Task{TaskID: "task-1", Prompt: "do something"}
+	task := molecular.Task{TaskID: "task-1", Prompt: "do something", Labels: map[string]string{"epic": "billing"}}
 	ctx := context.Background()
 
-	if err := Execute(ctx, task); err != nil {
+	if _, err := Execute(ctx, task, Runner{}); err != nil {
 		t.Fatalf("execute: %v", err)
 	}
 
@@ -77,6 +80,15 @@ func TestExecute_EmitsSpans(t *testing.T) {
 			if !hasTaskID {
 				t.Fatalf("expected span to include task.id attribute")
 			}
+			hasLabel := false
+			for _, kv := range s.Attributes {
+				if kv.Key == attribute.Key("silicon.label.epic") && kv.Value.AsString() == "billing" {
+					hasLabel = true
+				}
+			}
+			if !hasLabel {
+				t.Fatalf("expected span to include silicon.label.epic attribute")
+			}
 		}
 	}
 	if !found {
@@ -101,7 +113,7 @@ func TestExecute_Cancelled_EmitsCancelledEvent(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
-	err := Execute(ctx, api.Task{TaskID: "task-1", Prompt: "ignored"})
+	_, err := Execute(ctx, molecular.Task{TaskID: "task-1", Prompt: "ignored"}, Runner{})
 	if err == nil {
 		t.Fatalf("expected error")
 	}
@@ -130,3 +142,96 @@ func TestExecute_Cancelled_EmitsCancelledEvent(t *testing.T) {
 		t.Fatalf("expected task.cancelled event")
 	}
 }
+
+func TestExecute_FeedbackEvents(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(prev)
+
+	task := molecular.Task{TaskID: "task-1", LatestAttempt: &molecular.Attempt{Feedback: []molecular.Feedback{
+		{Message: "use the v2 client", Author: "ana", CreatedAt: "2026-01-02T03:04:05Z", Attempt: 2},
+	}}}
+	if _, err := Execute(context.Background(), task, Runner{}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	var notes int64 = -1
+	for _, s := range exp.GetSpans() {
+		if s.Name != "silicon.attempt" {
+			continue
+		}
+		for _, kv := range s.Attributes {
+			if kv.Key == "silicon.attempt.feedback_notes" {
+				notes = kv.Value.AsInt64()
+			}
+		}
+		// the decision itself is recorded once, on the feedback request
+		for _, ev := range s.Events {
+			if ev.Name == "decision.human_feedback" {
+				t.Fatalf("unexpected decision.human_feedback event on silicon.attempt")
+			}
+		}
+	}
+	if notes != 1 {
+		t.Fatalf("expected silicon.attempt.feedback_notes=1, got %d", notes)
+	}
+}
+
+func TestExecute_Usage(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(prev)
+
+	dir := t.TempDir()
+	if u, err := ReadUsage(dir); u != nil || err != nil {
+		t.Fatalf("no report: %+v %v", u, err)
+	}
+	results := filepath.Join(dir, artifacts.DirResults)
+	if err := os.MkdirAll(results, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(results, UsageFile), []byte(`{"model":"m1","input_tokens":1000,"output_tokens":200,"cost_usd":0.5}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	task := molecular.Task{
+		TaskID:        "task-1",
+		Usage:         &molecular.Usage{InputTokens: 10, OutputTokens: 20, CostUSD: 0.25},
+		LatestAttempt: &molecular.Attempt{ArtifactsDir: dir},
+	}
+	u, err := Execute(context.Background(), task, Runner{})
+	if err != nil || u == nil || *u != (molecular.Usage{Model: "m1", InputTokens: 1000, OutputTokens: 200, CostUSD: 0.5}) {
+		t.Fatalf("execute: %+v %v", u, err)
+	}
+
+	want := map[string]map[attribute.Key]attribute.Value{
+		"silicon.attempt": {"silicon.usage.model": attribute.StringValue("m1"), "silicon.usage.input_tokens": attribute.Int64Value(1000), "silicon.usage.cost_usd": attribute.Float64Value(0.5)},
+		"silicon.task":    {"silicon.usage.input_tokens": attribute.Int64Value(1010), "silicon.usage.output_tokens": attribute.Int64Value(220), "silicon.usage.cost_usd": attribute.Float64Value(0.75)},
+	}
+	for _, s := range exp.GetSpans() {
+		for _, kv := range s.Attributes {
+			if v, ok := want[s.Name][kv.Key]; ok {
+				if kv.Value != v {
+					t.Fatalf("%s %s = %v, want %v", s.Name, kv.Key, kv.Value.Emit(), v.Emit())
+				}
+				delete(want[s.Name], kv.Key)
+			}
+		}
+	}
+	for name, missing := range want {
+		if len(missing) > 0 {
+			t.Fatalf("%s is missing %v", name, missing)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(results, UsageFile), []byte(`{"input_tokens":-1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadUsage(dir); err == nil {
+		t.Fatalf("expected error for negative usage")
+	}
+}
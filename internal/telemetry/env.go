@@ -0,0 +1,117 @@
+package telemetry
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// FromEnv overlays the standard OTEL_* environment variables on base:
+//
+//	OTEL_SERVICE_NAME                service name, over service.name in the resource attributes
+//	OTEL_RESOURCE_ATTRIBUTES         key=value,... resource attributes
+//	OTEL_TRACES_EXPORTER             otlp, console (ExporterStdout) or none
+//	OTEL_EXPORTER_OTLP_ENDPOINT      collector base URL
+//	OTEL_EXPORTER_OTLP_PROTOCOL      http/protobuf or grpc
+//	OTEL_EXPORTER_OTLP_HEADERS       key=value,... headers sent with every export
+//	OTEL_EXPORTER_OTLP_INSECURE      true to skip TLS for a scheme-less endpoint
+//	OTEL_TRACES_SAMPLER              sampler name, e.g. parentbased_traceidratio
+//	OTEL_TRACES_SAMPLER_ARG          ratio for the traceidratio samplers, default 1
+//
+// Unset variables leave base as it is. Values are validated here so a typo
+// fails at startup rather than silently disabling export.
+func FromEnv(base Config) (Config, error) {
+	return fromEnv(base, os.Getenv)
+}
+
+func fromEnv(cfg Config, getenv func(string) string) (Config, error) {
+	if v := getenv("OTEL_RESOURCE_ATTRIBUTES"); v != "" {
+		attrs, err := parseKeyValues(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("OTEL_RESOURCE_ATTRIBUTES: %w", err)
+		}
+		if name, ok := attrs["service.name"]; ok {
+			cfg.ServiceName = name
+			delete(attrs, "service.name")
+		}
+		cfg.ResourceAttributes = attrs
+	}
+	if v := getenv("OTEL_SERVICE_NAME"); v != "" {
+		cfg.ServiceName = v
+	}
+	switch v := getenv("OTEL_TRACES_EXPORTER"); v {
+	case "":
+	case "otlp":
+		cfg.Exporter = ExporterOTLP
+	case "console":
+		cfg.Exporter = ExporterStdout
+	case "none":
+		cfg.Exporter = ExporterNone
+	default:
+		return Config{}, fmt.Errorf("OTEL_TRACES_EXPORTER: unsupported exporter %q: want otlp, console or none", v)
+	}
+	if v := getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); v != "" {
+		cfg.OTLPEndpoint = v
+	}
+	if v := getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); v != "" {
+		if v != ProtocolHTTP && v != ProtocolGRPC {
+			return Config{}, fmt.Errorf("OTEL_EXPORTER_OTLP_PROTOCOL: unsupported protocol %q: want %s or %s", v, ProtocolHTTP, ProtocolGRPC)
+		}
+		cfg.Protocol = v
+	}
+	if v := getenv("OTEL_EXPORTER_OTLP_HEADERS"); v != "" {
+		headers, err := parseKeyValues(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("OTEL_EXPORTER_OTLP_HEADERS: %w", err)
+		}
+		cfg.Headers = headers
+	}
+	if v := getenv("OTEL_EXPORTER_OTLP_INSECURE"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("OTEL_EXPORTER_OTLP_INSECURE: %q is not a boolean", v)
+		}
+		cfg.Insecure = b
+	}
+	if v := getenv("OTEL_TRACES_SAMPLER"); v != "" {
+		cfg.Sampler = v
+		if strings.HasSuffix(v, "traceidratio") {
+			cfg.SamplerRatio = 1
+		}
+	}
+	if v := getenv("OTEL_TRACES_SAMPLER_ARG"); v != "" && strings.HasSuffix(cfg.Sampler, "traceidratio") {
+		r, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("OTEL_TRACES_SAMPLER_ARG: %q is not a number", v)
+		}
+		cfg.SamplerRatio = r
+	}
+	if _, err := newSampler(cfg); err != nil {
+		return Config{}, fmt.Errorf("OTEL_TRACES_SAMPLER: %w", err)
+	}
+	return cfg, nil
+}
+
+// parseKeyValues parses the comma-separated key=value lists the OTEL_*
+// variables use, percent-decoding the values.
+func parseKeyValues(s string) (map[string]string, error) {
+	kv := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid entry %q: want key=value", pair)
+		}
+		dv, err := url.PathUnescape(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %q: %w", k, err)
+		}
+		kv[k] = dv
+	}
+	return kv, nil
+}
@@ -0,0 +1,187 @@
+package telemetry
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	base := Config{ServiceName: "svc", ServiceVersion: "v1"}
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    Config
+		wantErr string
+	}{
+		{
+			name: "unset keeps base",
+			want: base,
+		},
+		{
+			name: "exporter settings",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_ENDPOINT": "https://collector.example:4317",
+				"OTEL_EXPORTER_OTLP_PROTOCOL": "grpc",
+				"OTEL_EXPORTER_OTLP_HEADERS":  "authorization=Bearer%20abc, x-tenant = team-a",
+				"OTEL_EXPORTER_OTLP_INSECURE": "true",
+			},
+			want: Config{
+				ServiceName: "svc", ServiceVersion: "v1",
+				OTLPEndpoint: "https://collector.example:4317",
+				Protocol:     ProtocolGRPC,
+				Headers:      map[string]string{"authorization": "Bearer abc", "x-tenant": "team-a"},
+				Insecure:     true,
+			},
+		},
+		{
+			name: "console exporter",
+			env:  map[string]string{"OTEL_TRACES_EXPORTER": "console"},
+			want: Config{ServiceName: "svc", ServiceVersion: "v1", Exporter: ExporterStdout},
+		},
+		{
+			name: "service name from resource attributes",
+			env:  map[string]string{"OTEL_RESOURCE_ATTRIBUTES": "service.name=from-attrs,deployment.environment=dev"},
+			want: Config{
+				ServiceName: "from-attrs", ServiceVersion: "v1",
+				ResourceAttributes: map[string]string{"deployment.environment": "dev"},
+			},
+		},
+		{
+			name: "OTEL_SERVICE_NAME wins over resource attributes",
+			env: map[string]string{
+				"OTEL_SERVICE_NAME":        "from-env",
+				"OTEL_RESOURCE_ATTRIBUTES": "service.name=from-attrs",
+			},
+			want: Config{ServiceName: "from-env", ServiceVersion: "v1", ResourceAttributes: map[string]string{}},
+		},
+		{
+			name: "ratio sampler with arg",
+			env:  map[string]string{"OTEL_TRACES_SAMPLER": "parentbased_traceidratio", "OTEL_TRACES_SAMPLER_ARG": "0.25"},
+			want: Config{ServiceName: "svc", ServiceVersion: "v1", Sampler: "parentbased_traceidratio", SamplerRatio: 0.25},
+		},
+		{
+			name: "ratio sampler defaults to 1",
+			env:  map[string]string{"OTEL_TRACES_SAMPLER": "traceidratio"},
+			want: Config{ServiceName: "svc", ServiceVersion: "v1", Sampler: "traceidratio", SamplerRatio: 1},
+		},
+		{
+			name: "arg ignored for other samplers",
+			env:  map[string]string{"OTEL_TRACES_SAMPLER": "always_off", "OTEL_TRACES_SAMPLER_ARG": "nope"},
+			want: Config{ServiceName: "svc", ServiceVersion: "v1", Sampler: "always_off"},
+		},
+		{
+			name:    "unknown protocol",
+			env:     map[string]string{"OTEL_EXPORTER_OTLP_PROTOCOL": "http/json"},
+			wantErr: "OTEL_EXPORTER_OTLP_PROTOCOL",
+		},
+		{
+			name:    "unknown exporter",
+			env:     map[string]string{"OTEL_TRACES_EXPORTER": "zipkin"},
+			wantErr: "OTEL_TRACES_EXPORTER",
+		},
+		{
+			name:    "malformed headers",
+			env:     map[string]string{"OTEL_EXPORTER_OTLP_HEADERS": "authorization"},
+			wantErr: "OTEL_EXPORTER_OTLP_HEADERS",
+		},
+		{
+			name:    "malformed resource attributes",
+			env:     map[string]string{"OTEL_RESOURCE_ATTRIBUTES": "team=%zz"},
+			wantErr: "OTEL_RESOURCE_ATTRIBUTES",
+		},
+		{
+			name:    "insecure not a boolean",
+			env:     map[string]string{"OTEL_EXPORTER_OTLP_INSECURE": "sure"},
+			wantErr: "OTEL_EXPORTER_OTLP_INSECURE",
+		},
+		{
+			name:    "unknown sampler",
+			env:     map[string]string{"OTEL_TRACES_SAMPLER": "jaeger_remote"},
+			wantErr: "OTEL_TRACES_SAMPLER",
+		},
+		{
+			name:    "ratio out of range",
+			env:     map[string]string{"OTEL_TRACES_SAMPLER": "traceidratio", "OTEL_TRACES_SAMPLER_ARG": "1.5"},
+			wantErr: "OTEL_TRACES_SAMPLER",
+		},
+		{
+			name:    "ratio not a number",
+			env:     map[string]string{"OTEL_TRACES_SAMPLER": "traceidratio", "OTEL_TRACES_SAMPLER_ARG": "half"},
+			wantErr: "OTEL_TRACES_SAMPLER_ARG",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fromEnv(base, func(k string) string { return tt.env[k] })
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error mentioning %s, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigEndpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    endpoint
+		wantErr bool
+	}{
+		{name: "http default", cfg: Config{}, want: endpoint{host: "127.0.0.1:4318", insecure: true}},
+		{name: "grpc default", cfg: Config{Protocol: ProtocolGRPC}, want: endpoint{host: "127.0.0.1:4317", insecure: true}},
+		{name: "https with base path", cfg: Config{OTLPEndpoint: "https://otel.example/ingest/"}, want: endpoint{host: "otel.example", path: "/ingest"}},
+		{name: "host:port", cfg: Config{OTLPEndpoint: "collector:4317", Protocol: ProtocolGRPC}, want: endpoint{host: "collector:4317"}},
+		{name: "host:port insecure", cfg: Config{OTLPEndpoint: "collector:4317", Insecure: true}, want: endpoint{host: "collector:4317", insecure: true}},
+		{name: "unknown protocol", cfg: Config{Protocol: "http/json"}, wantErr: true},
+		{name: "no host", cfg: Config{OTLPEndpoint: "http:///v1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cfg.endpoint()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSampler(t *testing.T) {
+	tests := []struct {
+		cfg     Config
+		want    string // prefix of Description()
+		wantErr bool
+	}{
+		{cfg: Config{}, want: "ParentBased{root:AlwaysOnSampler"},
+		{cfg: Config{Sampler: "always_on"}, want: "AlwaysOnSampler"},
+		{cfg: Config{Sampler: "always_off"}, want: "AlwaysOffSampler"},
+		{cfg: Config{Sampler: "traceidratio", SamplerRatio: 0.5}, want: "TraceIDRatioBased{0.5}"},
+		{cfg: Config{Sampler: "parentbased_always_off"}, want: "ParentBased{root:AlwaysOffSampler"},
+		{cfg: Config{Sampler: "parentbased_traceidratio", SamplerRatio: 0.1}, want: "ParentBased{root:TraceIDRatioBased{0.1}"},
+		{cfg: Config{Sampler: "traceidratio", SamplerRatio: -0.1}, wantErr: true},
+		{cfg: Config{Sampler: "xray"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cfg.Sampler, func(t *testing.T) {
+			s, err := newSampler(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !strings.HasPrefix(s.Description(), tt.want) {
+				t.Fatalf("got %q, want prefix %q", s.Description(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package telemetry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, SpansFile)
+	if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := openRotatingFile(dir, 8, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every write after the first overflows 8 bytes, and with two backups
+	// the oldest contents ("old\na\n") are dropped
+	for _, line := range []string{"a\n", "bbbbbb\n", "cc\n", "dddddd\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		SpansFile:        "dddddd\n",
+		SpansFile + ".1": "cc\n",
+		SpansFile + ".2": "bbbbbb\n",
+	} {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(b) != want {
+			t.Errorf("%s: got %q (%v), want %q", name, b, err, want)
+		}
+	}
+	if _, err := r.Write([]byte("x")); err == nil {
+		t.Fatalf("expected write after close to fail")
+	}
+}
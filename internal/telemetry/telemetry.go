@@ -3,88 +3,296 @@ package telemetry
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/url"
+	"os"
+	"sort"
+	"strings"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	otlptracehttp "go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/metric/global"
 	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	sdkresource "go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
-// Config controls telemetry initialization behavior.
+// OTLP protocols for Config.Protocol.
+const (
+	ProtocolHTTP = "http/protobuf"
+	ProtocolGRPC = "grpc"
+)
+
+// Exporter modes for Config.Exporter.
+const (
+	// ExporterOTLP sends traces and metrics to an OTLP collector.
+	ExporterOTLP = "otlp"
+	// ExporterStdout prints spans to stdout as indented JSON.
+	ExporterStdout = "stdout"
+	// ExporterFile appends spans as JSON lines to SpansFile in FileDir,
+	// rotating it as it grows.
+	ExporterFile = "file"
+	// ExporterNone exports nothing; spans are not recorded.
+	ExporterNone = "none"
+)
+
+// Config controls telemetry initialization behavior. FromEnv fills it from
+// the standard OTEL_* environment variables.
 type Config struct {
 	ServiceName    string
 	ServiceVersion string
-	OTLPEndpoint   string
-	Insecure       bool
+	// Exporter is one of the Exporter* modes; empty means ExporterOTLP.
+	// Only ExporterOTLP exports metrics.
+	Exporter string
+	// FileDir holds the span files for ExporterFile.
+	FileDir string
+	// OTLPEndpoint is the collector's base URL, or host:port. For HTTP a
+	// path in the URL prefixes /v1/traces and /v1/metrics. Defaults to
+	// http://127.0.0.1:4318, or :4317 for gRPC.
+	OTLPEndpoint string
+	Insecure     bool
+	// Protocol is ProtocolHTTP (the default) or ProtocolGRPC.
+	Protocol string
+	// Headers are sent with every export, e.g. for collector auth.
+	Headers map[string]string
+	// Sampler names the trace sampler as OTEL_TRACES_SAMPLER does; empty
+	// means parentbased_always_on. SamplerRatio is the fraction of traces
+	// the traceidratio samplers keep.
+	Sampler      string
+	SamplerRatio float64
+	// ResourceAttributes describe the process on traces and metrics.
+	// ServiceName and ServiceVersion take precedence over them.
+	ResourceAttributes map[string]string
 }
 
-// Init initializes OpenTelemetry tracing using an OTLP/HTTP exporter.
-// It sets global propagators and the global TracerProvider. Returns a
-// shutdown function that will attempt to flush and stop the provider.
+// Init initializes OpenTelemetry for cfg's exporter mode. In the default
+// OTLP mode traces and metrics are both exported to the same collector. It
+// sets global propagators, the global TracerProvider and, for OTLP, the
+// global MeterProvider. Settings the mode depends on are checked up front,
+// so a bad endpoint or an unwritable span dir fails here rather than on the
+// first export. Returns a shutdown function that will attempt to flush and
+// stop the providers.
 func Init(ctx context.Context, cfg Config) (func(context.Context) error, error) {
 	if cfg.ServiceName == "" {
 		return nil, errors.New("service name required")
 	}
+	if _, err := newSampler(cfg); err != nil {
+		return nil, err
+	}
 
-	ep := cfg.OTLPEndpoint
-	if ep == "" {
-		ep = "http://127.0.0.1:4318"
+	switch cfg.Exporter {
+	case "", ExporterOTLP:
+		return initOTLP(ctx, cfg)
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, err
+		}
+		return initSpans(exporter, cfg, nil)
+	case ExporterFile:
+		if cfg.FileDir == "" {
+			return nil, errors.New("file exporter needs a directory for its span files")
+		}
+		f, err := openRotatingFile(cfg.FileDir, defaultFileMaxBytes, defaultFileMaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return initSpans(exporter, cfg, f.Close)
+	case ExporterNone:
+		// keep propagating incoming trace context to outgoing calls
+		setPropagator()
+		return func(context.Context) error { return nil }, nil
 	}
+	return nil, fmt.Errorf("unsupported exporter %q: want %s, %s, %s or %s", cfg.Exporter, ExporterOTLP, ExporterStdout, ExporterFile, ExporterNone)
+}
 
-	u, err := url.Parse(ep)
+// initOTLP exports traces and metrics over OTLP.
+func initOTLP(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	ep, err := cfg.endpoint()
 	if err != nil {
 		return nil, err
 	}
 
-	endpoint := u.Host
-	if endpoint == "" {
-		// fallback if user passed host:port without scheme
-		endpoint = u.Path
+	exporter, err := newSpanExporter(ctx, cfg, ep)
+	if err != nil {
+		return nil, err
 	}
-
-	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
-	if cfg.Insecure || u.Scheme == "http" {
-		opts = append(opts, otlptracehttp.WithInsecure())
+	tp, shutdown, err := newTracerProviderWithExporter(exporter, cfg)
+	if err != nil {
+		// best-effort cleanup of exporter
+		_ = exporter.Shutdown(ctx)
+		return nil, err
 	}
 
-	exporter, err := otlptracehttp.New(ctx, opts...)
+	mexporter, err := newMetricExporter(ctx, cfg, ep)
 	if err != nil {
+		_ = shutdown(ctx)
 		return nil, err
 	}
+	mp, err := newMeterProviderWithReader(sdkmetric.NewPeriodicReader(mexporter), cfg)
+	if err != nil {
+		_ = mexporter.Shutdown(ctx)
+		_ = shutdown(ctx)
+		return nil, err
+	}
+
+	// set global propagator, tracer provider and meter provider
+	setPropagator()
+	otel.SetTracerProvider(tp)
+	global.SetMeterProvider(mp)
 
+	return func(ctx context.Context) error {
+		// flush both even if one fails
+		return errors.Join(shutdown(ctx), mp.Shutdown(ctx))
+	}, nil
+}
+
+// initSpans exports spans only, then calls closeFn, if any, on shutdown.
+func initSpans(exporter sdktrace.SpanExporter, cfg Config, closeFn func() error) (func(context.Context) error, error) {
 	tp, shutdown, err := newTracerProviderWithExporter(exporter, cfg)
 	if err != nil {
-		// best-effort cleanup of exporter
-		_ = exporter.Shutdown(ctx)
+		_ = exporter.Shutdown(context.Background())
+		if closeFn != nil {
+			_ = closeFn()
+		}
 		return nil, err
 	}
+	setPropagator()
+	otel.SetTracerProvider(tp)
+	return func(ctx context.Context) error {
+		err := shutdown(ctx)
+		if closeFn != nil {
+			err = errors.Join(err, closeFn())
+		}
+		return err
+	}, nil
+}
 
-	// set global propagator and tracer provider
+func setPropagator() {
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
-	otel.SetTracerProvider(tp)
+}
+
+// endpoint is where the exporters send to.
+type endpoint struct {
+	// host is host:port; path prefixes the signal paths over HTTP.
+	host, path string
+	insecure   bool
+}
+
+// endpoint resolves cfg's collector endpoint for its protocol.
+func (cfg Config) endpoint() (endpoint, error) {
+	ep := cfg.OTLPEndpoint
+	switch cfg.Protocol {
+	case "", ProtocolHTTP:
+		if ep == "" {
+			ep = "http://127.0.0.1:4318"
+		}
+	case ProtocolGRPC:
+		if ep == "" {
+			ep = "http://127.0.0.1:4317"
+		}
+	default:
+		return endpoint{}, fmt.Errorf("unsupported OTLP protocol %q: want %s or %s", cfg.Protocol, ProtocolHTTP, ProtocolGRPC)
+	}
+	if !strings.Contains(ep, "://") {
+		// host:port without a scheme
+		return endpoint{host: ep, insecure: cfg.Insecure}, nil
+	}
+	u, err := url.Parse(ep)
+	if err != nil {
+		return endpoint{}, err
+	}
+	if u.Host == "" {
+		return endpoint{}, fmt.Errorf("OTLP endpoint %q has no host", ep)
+	}
+	return endpoint{host: u.Host, path: strings.TrimSuffix(u.Path, "/"), insecure: cfg.Insecure || u.Scheme == "http"}, nil
+}
+
+func newSpanExporter(ctx context.Context, cfg Config, ep endpoint) (sdktrace.SpanExporter, error) {
+	if cfg.Protocol == ProtocolGRPC {
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(ep.host), otlptracegrpc.WithHeaders(cfg.Headers)}
+		if ep.insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	}
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(ep.host), otlptracehttp.WithHeaders(cfg.Headers)}
+	if ep.path != "" {
+		opts = append(opts, otlptracehttp.WithURLPath(ep.path+"/v1/traces"))
+	}
+	if ep.insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	return otlptracehttp.New(ctx, opts...)
+}
 
-	return shutdown, nil
+func newMetricExporter(ctx context.Context, cfg Config, ep endpoint) (sdkmetric.Exporter, error) {
+	if cfg.Protocol == ProtocolGRPC {
+		opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithEndpoint(ep.host), otlpmetricgrpc.WithHeaders(cfg.Headers)}
+		if ep.insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+		return otlpmetricgrpc.New(ctx, opts...)
+	}
+	opts := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(ep.host), otlpmetrichttp.WithHeaders(cfg.Headers)}
+	if ep.path != "" {
+		opts = append(opts, otlpmetrichttp.WithURLPath(ep.path+"/v1/metrics"))
+	}
+	if ep.insecure {
+		opts = append(opts, otlpmetrichttp.WithInsecure())
+	}
+	return otlpmetrichttp.New(ctx, opts...)
+}
+
+// newSampler returns the trace sampler cfg names.
+func newSampler(cfg Config) (sdktrace.Sampler, error) {
+	if strings.HasSuffix(cfg.Sampler, "traceidratio") && (cfg.SamplerRatio < 0 || cfg.SamplerRatio > 1) {
+		return nil, fmt.Errorf("sampler ratio %v: want a value from 0 to 1", cfg.SamplerRatio)
+	}
+	switch cfg.Sampler {
+	case "", "parentbased_always_on":
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()), nil
+	case "parentbased_always_off":
+		return sdktrace.ParentBased(sdktrace.NeverSample()), nil
+	case "parentbased_traceidratio":
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SamplerRatio)), nil
+	case "always_on":
+		return sdktrace.AlwaysSample(), nil
+	case "always_off":
+		return sdktrace.NeverSample(), nil
+	case "traceidratio":
+		return sdktrace.TraceIDRatioBased(cfg.SamplerRatio), nil
+	}
+	return nil, fmt.Errorf("unsupported trace sampler %q", cfg.Sampler)
 }
 
 // newTracerProviderWithExporter creates a TracerProvider wired to the
 // provided SpanExporter. This helper is unexported to allow tests to
 // supply in-memory exporters.
 func newTracerProviderWithExporter(exporter sdktrace.SpanExporter, cfg Config) (*sdktrace.TracerProvider, func(context.Context) error, error) {
-	// resource with basic service attributes
-	res, err := sdkresource.New(context.Background(), sdkresource.WithAttributes(
-		attribute.String("service.name", cfg.ServiceName),
-		attribute.String("service.version", cfg.ServiceVersion),
-	))
+	res, err := newResource(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	sampler, err := newSampler(cfg)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	bsp := sdktrace.NewBatchSpanProcessor(exporter)
 	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+		sdktrace.WithSampler(sampler),
 		sdktrace.WithResource(res),
 		sdktrace.WithSpanProcessor(bsp),
 	)
@@ -94,3 +302,32 @@ func newTracerProviderWithExporter(exporter sdktrace.SpanExporter, cfg Config) (
 	}
 	return tp, shutdown, nil
 }
+
+// newMeterProviderWithReader creates a MeterProvider collected by reader:
+// a periodic OTLP reader in Init, a manual in-memory reader in tests.
+func newMeterProviderWithReader(reader sdkmetric.Reader, cfg Config) (*sdkmetric.MeterProvider, error) {
+	res, err := newResource(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return sdkmetric.NewMeterProvider(sdkmetric.WithResource(res), sdkmetric.WithReader(reader)), nil
+}
+
+// newResource describes the service to both providers.
+func newResource(cfg Config) (*sdkresource.Resource, error) {
+	keys := make([]string, 0, len(cfg.ResourceAttributes))
+	for k := range cfg.ResourceAttributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]attribute.KeyValue, 0, len(keys)+2)
+	for _, k := range keys {
+		attrs = append(attrs, attribute.String(k, cfg.ResourceAttributes[k]))
+	}
+	// basic service attributes, last so they win
+	attrs = append(attrs,
+		attribute.String("service.name", cfg.ServiceName),
+		attribute.String("service.version", cfg.ServiceVersion),
+	)
+	return sdkresource.New(context.Background(), sdkresource.WithAttributes(attrs...))
+}
@@ -2,9 +2,17 @@ package telemetry
 
 import (
 	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/instrument"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
@@ -57,3 +65,113 @@ func TestNewTracerProviderWithExporter_EmitsSpans(t *testing.T) {
 		t.Fatalf("expected resource to include service.name=testsvc")
 	}
 }
+
+func TestNewMeterProviderWithReader_CollectsMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp, err := newMeterProviderWithReader(reader, Config{ServiceName: "testsvc", ServiceVersion: "v0"})
+	if err != nil {
+		t.Fatalf("new meter provider: %v", err)
+	}
+	ctx := context.Background()
+	counter, err := mp.Meter("test").Int64Counter("test.requests", instrument.WithDescription("requests"))
+	if err != nil {
+		t.Fatalf("counter: %v", err)
+	}
+	counter.Add(ctx, 2, attribute.String("route", "/x"))
+	counter.Add(ctx, 1, attribute.String("route", "/x"))
+
+	rm, err := reader.Collect(ctx)
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	if v, ok := rm.Resource.Set().Value("service.name"); !ok || v.AsString() != "testsvc" {
+		t.Fatalf("expected resource to include service.name=testsvc")
+	}
+	if len(rm.ScopeMetrics) != 1 || len(rm.ScopeMetrics[0].Metrics) != 1 {
+		t.Fatalf("unexpected metrics: %+v", rm.ScopeMetrics)
+	}
+	m := rm.ScopeMetrics[0].Metrics[0]
+	sum, ok := m.Data.(metricdata.Sum[int64])
+	if m.Name != "test.requests" || !ok || len(sum.DataPoints) != 1 || sum.DataPoints[0].Value != 3 {
+		t.Fatalf("unexpected metric: %+v", m)
+	}
+
+	if err := mp.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+}
+
+func TestInit_ExporterModes(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(prev)
+	readOnly := filepath.Join(t.TempDir(), "ro")
+	if err := os.WriteFile(readOnly, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{name: "none", cfg: Config{Exporter: ExporterNone}},
+		{name: "stdout", cfg: Config{Exporter: ExporterStdout}},
+		{name: "file", cfg: Config{Exporter: ExporterFile, FileDir: t.TempDir()}},
+		{name: "file without dir", cfg: Config{Exporter: ExporterFile}, wantErr: "directory"},
+		{name: "file dir is a file", cfg: Config{Exporter: ExporterFile, FileDir: readOnly}, wantErr: "span file dir"},
+		{name: "unknown", cfg: Config{Exporter: "jaeger"}, wantErr: "unsupported exporter"},
+		{name: "otlp bad protocol", cfg: Config{Exporter: ExporterOTLP, Protocol: "http/json"}, wantErr: "unsupported OTLP protocol"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.ServiceName = "testsvc"
+			shutdown, err := Init(context.Background(), tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("init: %v", err)
+			}
+			if err := shutdown(context.Background()); err != nil {
+				t.Fatalf("shutdown: %v", err)
+			}
+		})
+	}
+}
+
+func TestInit_FileExporterWritesJSONLines(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(prev)
+	dir := filepath.Join(t.TempDir(), "spans")
+
+	shutdown, err := Init(context.Background(), Config{ServiceName: "testsvc", Exporter: ExporterFile, FileDir: dir})
+	if err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	tr := otel.Tracer("test")
+	for _, name := range []string{"first", "second"} {
+		_, sp := tr.Start(context.Background(), name)
+		sp.End()
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, SpansFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d:\n%s", len(lines), b)
+	}
+	for i, want := range []string{"first", "second"} {
+		var span struct{ Name string }
+		if err := json.Unmarshal([]byte(lines[i]), &span); err != nil || span.Name != want {
+			t.Fatalf("line %d: %v %q", i, err, lines[i])
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Package client is the first Go client for Silicon's /v1 HTTP API.
+//
+// Deprecated: use github.com/throw-if-null/molecular/pkg/molecular, which
+// has the same operations plus retries, event streaming and batch calls.
+// This package forwards to it and keeps its original method set and error
+// type.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+// The request and response types are those of pkg/molecular.
+type (
+	Task              = molecular.Task
+	CreateTaskRequest = molecular.CreateTaskRequest
+	CleanupResult     = molecular.CleanupResult
+	Artifact          = molecular.Artifact
+	LogsOptions       = molecular.LogsOptions
+	DiffOptions       = molecular.DiffOptions
+	CreateTaskOptions = molecular.CreateTaskOptions
+)
+
+// Client talks to a single Silicon server.
+//
+// Deprecated: use molecular.Client.
+type Client struct {
+	c *molecular.Client
+}
+
+// New returns a Client for the server at baseURL (e.g.
+// "http://127.0.0.1:8711"). A nil hc uses http.DefaultClient; pass a
+// configured client for TLS, Unix sockets or authentication.
+//
+// Deprecated: use molecular.New.
+func New(baseURL string, hc *http.Client) *Client {
+	return &Client{c: molecular.New(baseURL, hc)}
+}
+
+// Error is returned for every non-2xx response. API is set when the body
+// is Silicon's JSON error envelope; otherwise Body holds the raw response,
+// e.g. from a proxy in front of Silicon. It unwraps to the
+// *molecular.Error, so errors.Is works with the molecular.Err* sentinels.
+//
+// Deprecated: use molecular.Error.
+type Error struct {
+	StatusCode int
+	Status     string
+	API        *molecular.Error
+	Body       []byte
+
+	err *molecular.Error
+}
+
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Code returns the API error code, or "" when the response was not an API
+// error.
+func (e *Error) Code() molecular.ErrorCode {
+	if e.API == nil {
+		return ""
+	}
+	return e.API.Code
+}
+
+// wrap turns a *molecular.Error into an *Error; other errors pass through.
+func wrap(err error) error {
+	var me *molecular.Error
+	if !errors.As(err, &me) {
+		return err
+	}
+	e := &Error{StatusCode: me.StatusCode, Status: fmt.Sprintf("%d %s", me.StatusCode, http.StatusText(me.StatusCode)), err: me}
+	if me.Code != "" {
+		e.API = me
+	} else {
+		e.Body = []byte(strings.TrimPrefix(me.Message, e.Status+": "))
+	}
+	return e
+}
+
+// CreateTask submits a task (POST /v1/tasks).
+func (c *Client) CreateTask(ctx context.Context, req CreateTaskRequest, opts CreateTaskOptions) (*Task, error) {
+	t, err := c.c.CreateTask(ctx, req, opts)
+	return t, wrap(err)
+}
+
+// ListTasksOptions filter GET /v1/tasks.
+type ListTasksOptions struct {
+	// Limit caps the number of tasks returned; 0 means no limit.
+	Limit int
+}
+
+// ListTasks lists tasks (GET /v1/tasks).
+func (c *Client) ListTasks(ctx context.Context, opts ListTasksOptions) ([]Task, error) {
+	page, err := c.c.ListTasks(ctx, molecular.ListTasksOptions{Limit: opts.Limit})
+	if err != nil {
+		return nil, wrap(err)
+	}
+	return page.Tasks, nil
+}
+
+// GetTask fetches a task (GET /v1/tasks/{id}).
+func (c *Client) GetTask(ctx context.Context, id string) (*Task, error) {
+	t, err := c.c.GetTask(ctx, id)
+	return t, wrap(err)
+}
+
+// CancelTask cancels a running task (POST /v1/tasks/{id}/cancel).
+func (c *Client) CancelTask(ctx context.Context, id string) (*Task, error) {
+	t, err := c.c.CancelTask(ctx, id)
+	return t, wrap(err)
+}
+
+// CleanupTask removes a task's worktree and artifacts
+// (POST /v1/tasks/{id}/cleanup).
+func (c *Client) CleanupTask(ctx context.Context, id string) (*CleanupResult, error) {
+	res, err := c.c.CleanupTask(ctx, id)
+	return res, wrap(err)
+}
+
+// Logs streams Silicon's lifecycle log for a task
+// (GET /v1/tasks/{id}/logs). The caller must close the returned reader.
+func (c *Client) Logs(ctx context.Context, id string, opts LogsOptions) (io.ReadCloser, error) {
+	body, err := c.c.Logs(ctx, id, opts)
+	return body, wrap(err)
+}
+
+// ListArtifacts lists a task's artifacts (GET /v1/tasks/{id}/artifacts).
+func (c *Client) ListArtifacts(ctx context.Context, id string) ([]Artifact, error) {
+	list, err := c.c.ListArtifacts(ctx, id)
+	return list, wrap(err)
+}
+
+// GetArtifact streams a single artifact
+// (GET /v1/tasks/{id}/artifacts/{path}). rel is slash separated and
+// relative to the task's artifacts root. The caller must close the
+// returned reader.
+func (c *Client) GetArtifact(ctx context.Context, id, rel string) (io.ReadCloser, error) {
+	body, err := c.c.GetArtifact(ctx, id, rel)
+	return body, wrap(err)
+}
+
+// Diff streams the task's changes against its base commit. The caller must
+// close the returned reader.
+func (c *Client) Diff(ctx context.Context, id string, opts DiffOptions) (io.ReadCloser, error) {
+	body, err := c.c.Diff(ctx, id, opts)
+	return body, wrap(err)
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/throw-if-null/molecular/pkg/molecular"
+)
+
+func TestClient_RequestsAndErrors(t *testing.T) {
+	var gotKey, gotRaw string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/tasks", func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get(molecular.IdempotencyKeyHeader)
+		var req CreateTaskRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(Task{TaskID: req.TaskID, Prompt: req.Prompt, Status: "running"})
+	})
+	mux.HandleFunc("GET /v1/tasks", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("limit") != "1" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		_ = json.NewEncoder(w).Encode(molecular.TaskList{Tasks: []Task{{TaskID: "a"}}})
+	})
+	mux.HandleFunc("GET /v1/tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"code":"not_found","message":"task not found","trace_id":"abc"}`))
+	})
+	mux.HandleFunc("GET /v1/tasks/{id}/artifacts/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		gotRaw = r.URL.EscapedPath()
+		_, _ = w.Write([]byte("hello"))
+	})
+	mux.HandleFunc("GET /v1/tasks/{id}/diff", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("upstream down"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New(srv.URL+"/", nil)
+	ctx := context.Background()
+
+	task, err := c.CreateTask(ctx, CreateTaskRequest{TaskID: "t1", Prompt: "p"}, CreateTaskOptions{IdempotencyKey: "k1"})
+	if err != nil || task.TaskID != "t1" || task.Status != "running" || gotKey != "k1" {
+		t.Fatalf("create: task=%+v err=%v key=%q", task, err, gotKey)
+	}
+
+	tasks, err := c.ListTasks(ctx, ListTasksOptions{Limit: 1})
+	if err != nil || len(tasks) != 1 || tasks[0].TaskID != "a" {
+		t.Fatalf("list: %+v %v", tasks, err)
+	}
+
+	_, err = c.GetTask(ctx, "missing")
+	var ce *Error
+	if !errors.As(err, &ce) || ce.StatusCode != http.StatusNotFound || ce.Code() != molecular.CodeNotFound || ce.API.TraceID != "abc" {
+		t.Fatalf("get missing: %#v", err)
+	}
+	if !errors.Is(err, molecular.ErrNotFound) {
+		t.Fatalf("expected the molecular sentinel to match: %v", err)
+	}
+
+	body, err := c.GetArtifact(ctx, "t1", "attempts/001-carbon/prompts/a b.md")
+	if err != nil {
+		t.Fatalf("get artifact: %v", err)
+	}
+	b, _ := io.ReadAll(body)
+	body.Close()
+	if string(b) != "hello" || gotRaw != "/v1/tasks/t1/artifacts/attempts/001-carbon/prompts/a%20b.md" {
+		t.Fatalf("artifact: %q via %q", b, gotRaw)
+	}
+
+	// non-JSON error bodies are kept verbatim
+	_, err = c.Diff(ctx, "t1", DiffOptions{})
+	if !errors.As(err, &ce) || ce.API != nil || ce.Code() != "" || ce.Status != "400 Bad Request" || string(ce.Body) != "upstream down" {
+		t.Fatalf("diff: %#v", err)
+	}
+}
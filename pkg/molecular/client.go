@@ -0,0 +1,531 @@
+package molecular
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how Client retries requests that are safe to
+// repeat: GETs, and task submits carrying an idempotency key. Refused or
+// dropped connections, timeouts, and 429, 502, 503 and 504 responses are
+// retried with exponential backoff; a Retry-After header in seconds takes
+// precedence. A 429 with code budget_exceeded is not retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries; values below 2 disable
+	// retries.
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+}
+
+// DefaultRetryPolicy is the policy New installs.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, MinBackoff: 200 * time.Millisecond, MaxBackoff: 2 * time.Second}
+
+// Client talks to a single Silicon server. Its fields may be changed
+// before first use.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	Retry      RetryPolicy
+}
+
+// New returns a Client for the server at baseURL (e.g.
+// "http://127.0.0.1:8711"). A nil hc uses http.DefaultClient; pass a
+// configured client for TLS, Unix sockets or authentication.
+func New(baseURL string, hc *http.Client) *Client {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), HTTPClient: hc, Retry: DefaultRetryPolicy}
+}
+
+// CreateTaskOptions are request options for CreateTask that are not part
+// of the JSON body.
+type CreateTaskOptions struct {
+	// IdempotencyKey makes the request safe to retry; see
+	// IdempotencyKeyHeader. Submits without one are never retried.
+	IdempotencyKey string
+}
+
+// CreateTask submits a task (POST /v1/tasks).
+func (c *Client) CreateTask(ctx context.Context, req CreateTaskRequest, opts CreateTaskOptions) (*Task, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	hreq, err := c.newRequest(ctx, http.MethodPost, "/v1/tasks", nil, b)
+	if err != nil {
+		return nil, err
+	}
+	hreq.Header.Set("Content-Type", "application/json")
+	if opts.IdempotencyKey != "" {
+		hreq.Header.Set(IdempotencyKeyHeader, opts.IdempotencyKey)
+	}
+	var t Task
+	if err := c.doJSON(hreq, opts.IdempotencyKey != "", &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListTasksOptions filter, sort and page GET /v1/tasks. Zero values are
+// omitted.
+type ListTasksOptions struct {
+	Status string
+	Phase  string
+	// Prefix matches the start of task IDs.
+	Prefix string
+	// Selector filters by label, e.g. "epic=billing,owner!=bob"; see
+	// ParseSelector.
+	Selector string
+	// Labels keeps tasks whose labels have every one of these values.
+	Labels map[string]string
+	// Since and Until bound created_at; Until is exclusive.
+	Since, Until time.Time
+	// Sort is created_at or updated_at, "-" prefixed for descending. The
+	// server default is -created_at.
+	Sort string
+	// Limit is the page size; 0 returns every matching task.
+	Limit int
+	// Cursor is the NextCursor of the previous page.
+	Cursor string
+}
+
+func (o ListTasksOptions) query() url.Values {
+	q := url.Values{}
+	for k, v := range map[string]string{"status": o.Status, "phase": o.Phase, "prefix": o.Prefix, "selector": o.Selector, "sort": o.Sort, "cursor": o.Cursor} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(o.Labels)) {
+		q.Add("label", k+"="+o.Labels[k])
+	}
+	if !o.Since.IsZero() {
+		q.Set("since", o.Since.UTC().Format(time.RFC3339))
+	}
+	if !o.Until.IsZero() {
+		q.Set("until", o.Until.UTC().Format(time.RFC3339))
+	}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	return q
+}
+
+// ListTasks fetches one page of tasks (GET /v1/tasks).
+func (c *Client) ListTasks(ctx context.Context, opts ListTasksOptions) (*TaskList, error) {
+	var page TaskList
+	if err := c.getJSON(ctx, "/v1/tasks", opts.query(), &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// ListAllTasks follows NextCursor until the last page and returns every
+// matching task. opts.Limit sets the page size.
+func (c *Client) ListAllTasks(ctx context.Context, opts ListTasksOptions) ([]Task, error) {
+	var all []Task
+	for {
+		page, err := c.ListTasks(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page.Tasks...)
+		if page.NextCursor == "" {
+			return all, nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+// GetTask fetches a task (GET /v1/tasks/{id}).
+func (c *Client) GetTask(ctx context.Context, id string) (*Task, error) {
+	var t Task
+	if err := c.getJSON(ctx, taskPath(id), nil, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// CancelTask cancels a running task (POST /v1/tasks/{id}/cancel).
+func (c *Client) CancelTask(ctx context.Context, id string) (*Task, error) {
+	var t Task
+	if err := c.postJSON(ctx, taskPath(id, "cancel"), nil, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// CleanupTask removes a task's worktree and artifacts
+// (POST /v1/tasks/{id}/cleanup).
+func (c *Client) CleanupTask(ctx context.Context, id string) (*CleanupResult, error) {
+	var res CleanupResult
+	if err := c.postJSON(ctx, taskPath(id, "cleanup"), nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// AddFeedback adds a human note to a running task
+// (POST /v1/tasks/{id}/feedback). It is included in the prompt of the
+// task's next Carbon attempt.
+func (c *Client) AddFeedback(ctx context.Context, id string, req FeedbackRequest) (*Task, error) {
+	var t Task
+	if err := c.postJSON(ctx, taskPath(id, "feedback"), req, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// RetryTask starts a new task with the inputs of a finished one
+// (POST /v1/tasks/{id}/retry). The new task's RetryOf names the original.
+func (c *Client) RetryTask(ctx context.Context, id string, req RetryRequest) (*Task, error) {
+	var t Task
+	if err := c.postJSON(ctx, taskPath(id, "retry"), req, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// BatchCancel cancels every selected running task
+// (POST /v1/tasks:batchCancel). Per-task failures are reported in the
+// response, not as an error.
+func (c *Client) BatchCancel(ctx context.Context, req BatchRequest) (*BatchResponse, error) {
+	return c.batch(ctx, "batchCancel", req)
+}
+
+// BatchCleanup removes the artifacts and worktrees of every selected
+// finished task (POST /v1/tasks:batchCleanup).
+func (c *Client) BatchCleanup(ctx context.Context, req BatchRequest) (*BatchResponse, error) {
+	return c.batch(ctx, "batchCleanup", req)
+}
+
+// BatchRetry starts a new run of every selected finished task
+// (POST /v1/tasks:batchRetry).
+func (c *Client) BatchRetry(ctx context.Context, req BatchRequest) (*BatchResponse, error) {
+	return c.batch(ctx, "batchRetry", req)
+}
+
+func (c *Client) batch(ctx context.Context, op string, req BatchRequest) (*BatchResponse, error) {
+	var res BatchResponse
+	if err := c.postJSON(ctx, "/v1/tasks:"+op, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// LogsOptions select part of a task log.
+type LogsOptions struct {
+	// Tail returns only the last Tail lines; 0 returns the whole log.
+	Tail int
+	// Follow keeps the stream open and sends new lines until the task
+	// finishes or ctx is cancelled.
+	Follow bool
+}
+
+// Logs streams Silicon's lifecycle log for a task
+// (GET /v1/tasks/{id}/logs). The caller must close the returned reader.
+func (c *Client) Logs(ctx context.Context, id string, opts LogsOptions) (io.ReadCloser, error) {
+	q := url.Values{}
+	if opts.Tail > 0 {
+		q.Set("tail", strconv.Itoa(opts.Tail))
+	}
+	if opts.Follow {
+		q.Set("follow", "true")
+	}
+	return c.getStream(ctx, taskPath(id, "logs"), q)
+}
+
+// EventStream reads a task's events. It is not safe for concurrent use.
+type EventStream struct {
+	body io.ReadCloser
+	dec  *json.Decoder
+}
+
+// Next returns the next event. It returns io.EOF after the task's terminal
+// event, or when the server closes the stream.
+func (s *EventStream) Next() (Event, error) {
+	var e Event
+	if err := s.dec.Decode(&e); err != nil {
+		return Event{}, err
+	}
+	return e, nil
+}
+
+// Close stops the stream.
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
+
+// Events streams a task's lifecycle events with Seq greater than after
+// (GET /v1/tasks/{id}/events). Past events are replayed first; the stream
+// then follows the task until it finishes. The caller must close the
+// stream.
+func (c *Client) Events(ctx context.Context, id string, after int64) (*EventStream, error) {
+	q := url.Values{}
+	if after > 0 {
+		q.Set("after", strconv.FormatInt(after, 10))
+	}
+	body, err := c.getStream(ctx, taskPath(id, "events"), q)
+	if err != nil {
+		return nil, err
+	}
+	return &EventStream{body: body, dec: json.NewDecoder(body)}, nil
+}
+
+// Wait follows a task's events until it finishes and returns the final
+// task.
+func (c *Client) Wait(ctx context.Context, id string) (*Task, error) {
+	stream, err := c.Events(ctx, id, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	for {
+		e, err := stream.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("event stream for %s ended before the task finished", id)
+			}
+			return nil, err
+		}
+		if e.Terminal() {
+			return &e.Task, nil
+		}
+	}
+}
+
+// ListArtifacts lists a task's artifacts (GET /v1/tasks/{id}/artifacts).
+func (c *Client) ListArtifacts(ctx context.Context, id string) ([]Artifact, error) {
+	var list []Artifact
+	if err := c.getJSON(ctx, taskPath(id, "artifacts"), nil, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetArtifact streams a single artifact
+// (GET /v1/tasks/{id}/artifacts/{path}). rel is slash separated and
+// relative to the task's artifacts root. The caller must close the
+// returned reader.
+func (c *Client) GetArtifact(ctx context.Context, id, rel string) (io.ReadCloser, error) {
+	return c.getStream(ctx, taskPath(id, "artifacts")+"/"+escapeSegments(rel), nil)
+}
+
+// DiffOptions select the form of GET /v1/tasks/{id}/diff.
+type DiffOptions struct {
+	// Stat returns a diffstat summary instead of the full diff.
+	Stat bool
+	// Patch returns the task's commits as a `git am`-able mbox.
+	Patch bool
+}
+
+// Diff streams the task's changes against its base commit. The caller must
+// close the returned reader.
+func (c *Client) Diff(ctx context.Context, id string, opts DiffOptions) (io.ReadCloser, error) {
+	q := url.Values{}
+	if opts.Stat {
+		q.Set("stat", "true")
+	}
+	if opts.Patch {
+		q.Set("format", "patch")
+	}
+	return c.getStream(ctx, taskPath(id, "diff"), q)
+}
+
+// taskPath builds /v1/tasks/{id}[/sub].
+func taskPath(id string, sub ...string) string {
+	p := "/v1/tasks/" + url.PathEscape(id)
+	for _, s := range sub {
+		p += "/" + s
+	}
+	return p
+}
+
+// escapeSegments escapes each segment of a slash-separated path.
+func escapeSegments(p string) string {
+	parts := strings.Split(p, "/")
+	for i, s := range parts {
+		parts[i] = url.PathEscape(s)
+	}
+	return strings.Join(parts, "/")
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, q url.Values, body []byte) (*http.Request, error) {
+	u := c.BaseURL + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	var r io.Reader
+	if body != nil {
+		// a bytes.Reader lets NewRequest set GetBody for retries
+		r = bytes.NewReader(body)
+	}
+	return http.NewRequestWithContext(ctx, method, u, r)
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, q url.Values, v any) error {
+	req, err := c.newRequest(ctx, http.MethodGet, path, q, nil)
+	if err != nil {
+		return err
+	}
+	return c.doJSON(req, true, v)
+}
+
+// postJSON sends in, when non-nil, as the JSON body and decodes the
+// response into v. POSTs are never retried.
+func (c *Client) postJSON(ctx context.Context, path string, in, v any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, path, nil, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.doJSON(req, false, v)
+}
+
+// getStream returns a response body the caller reads at its own pace:
+// followed logs and events, or large downloads. The HTTP client's Timeout
+// would cut those off mid-read, as it covers the body too, so it does not
+// apply; ctx bounds the stream instead.
+func (c *Client) getStream(ctx context.Context, path string, q url.Values) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, path, q, nil)
+	if err != nil {
+		return nil, err
+	}
+	hc := c.HTTPClient
+	if hc.Timeout > 0 {
+		cp := *hc
+		cp.Timeout = 0
+		hc = &cp
+	}
+	resp, err := c.send(hc, req, true)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// doJSON sends req and decodes a successful response into v.
+func (c *Client) doJSON(req *http.Request, retry bool, v any) error {
+	resp, err := c.do(req, retry)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding %s %s response: %w", req.Method, req.URL.Path, err)
+	}
+	return nil
+}
+
+// do sends req, retrying per c.Retry when retry is set, and turns non-2xx
+// responses into *Error. On success the caller owns resp.Body.
+func (c *Client) do(req *http.Request, retry bool) (*http.Response, error) {
+	return c.send(c.HTTPClient, req, retry)
+}
+
+// send is do with an explicit HTTP client.
+func (c *Client) send(hc *http.Client, req *http.Request, retry bool) (*http.Response, error) {
+	attempts := 1
+	if retry && c.Retry.MaxAttempts > 1 {
+		attempts = c.Retry.MaxAttempts
+	}
+	for i := 1; ; i++ {
+		resp, err := hc.Do(req)
+		if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp, nil
+		}
+		if err == nil {
+			err = responseError(resp)
+		}
+		if i >= attempts || req.Context().Err() != nil || !retryable(err) {
+			return nil, err
+		}
+		t := time.NewTimer(c.backoff(i, resp))
+		select {
+		case <-req.Context().Done():
+			t.Stop()
+			return nil, req.Context().Err()
+		case <-t.C:
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// retryable reports whether a failed try may succeed if repeated. Of the
+// transport errors only refused or dropped connections and timeouts count;
+// TLS and DNS failures will not fix themselves. A 429 for a spent budget is
+// not retried either: it lasts until the cap resets.
+func retryable(err error) bool {
+	var e *Error
+	if errors.As(err, &e) {
+		switch e.StatusCode {
+		case http.StatusTooManyRequests:
+			return e.Code != CodeBudgetExceeded
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var ne net.Error
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		(errors.As(err, &ne) && ne.Timeout())
+}
+
+// backoff returns how long to wait before try n+1.
+func (c *Client) backoff(n int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s >= 0 {
+			return time.Duration(s) * time.Second
+		}
+	}
+	d := c.Retry.MinBackoff << (n - 1)
+	if c.Retry.MaxBackoff > 0 && (d > c.Retry.MaxBackoff || d <= 0) {
+		d = c.Retry.MaxBackoff
+	}
+	return d
+}
+
+// responseError reads a non-2xx response into an *Error. Bodies that are
+// not Silicon's JSON envelope are kept in Message verbatim.
+func responseError(resp *http.Response) error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	var e Error
+	if json.Unmarshal(body, &e) != nil || e.Code == "" {
+		e = Error{Message: fmt.Sprintf("%s: %s", resp.Status, body)}
+	}
+	e.StatusCode = resp.StatusCode
+	return &e
+}
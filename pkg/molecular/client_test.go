@@ -0,0 +1,240 @@
+package molecular
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_RequestsAndErrors(t *testing.T) {
+	var gotKey, gotRaw string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/tasks", func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get(IdempotencyKeyHeader)
+		var req CreateTaskRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(Task{TaskID: req.TaskID, Prompt: req.Prompt, Status: "running"})
+	})
+	mux.HandleFunc("GET /v1/tasks", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.RawQuery {
+		case "limit=1&status=running":
+			_ = json.NewEncoder(w).Encode(TaskList{Tasks: []Task{{TaskID: "a"}}, NextCursor: "c1"})
+		case "cursor=c1&limit=1&status=running":
+			_ = json.NewEncoder(w).Encode(TaskList{Tasks: []Task{{TaskID: "b"}}})
+		default:
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+	})
+	mux.HandleFunc("GET /v1/tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"code":"not_found","message":"task not found","trace_id":"abc"}`))
+	})
+	mux.HandleFunc("GET /v1/tasks/{id}/artifacts/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		gotRaw = r.URL.EscapedPath()
+		_, _ = w.Write([]byte("hello"))
+	})
+	mux.HandleFunc("POST /v1/tasks/{id}/cleanup", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("upstream down"))
+	})
+	mux.HandleFunc("POST /v1/tasks:batchCancel", func(w http.ResponseWriter, r *http.Request) {
+		var req BatchRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_ = json.NewEncoder(w).Encode(BatchResponse{Results: []BatchResult{
+			{TaskID: req.Selector, Task: &Task{TaskID: req.Selector, Status: "cancelled"}},
+			{TaskID: "b", Error: &Error{Code: CodeTaskNotRunning}},
+		}})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New(srv.URL+"/", nil)
+	ctx := context.Background()
+
+	task, err := c.CreateTask(ctx, CreateTaskRequest{TaskID: "t1", Prompt: "p"}, CreateTaskOptions{IdempotencyKey: "k1"})
+	if err != nil || task.TaskID != "t1" || task.Status != "running" || gotKey != "k1" {
+		t.Fatalf("create: task=%+v err=%v key=%q", task, err, gotKey)
+	}
+
+	page, err := c.ListTasks(ctx, ListTasksOptions{Status: "running", Limit: 1})
+	if err != nil || len(page.Tasks) != 1 || page.Tasks[0].TaskID != "a" || page.NextCursor != "c1" {
+		t.Fatalf("list: %+v %v", page, err)
+	}
+	all, err := c.ListAllTasks(ctx, ListTasksOptions{Status: "running", Limit: 1})
+	if err != nil || len(all) != 2 || all[1].TaskID != "b" {
+		t.Fatalf("list all: %+v %v", all, err)
+	}
+
+	_, err = c.GetTask(ctx, "missing")
+	var e *Error
+	if !errors.Is(err, ErrNotFound) || errors.Is(err, ErrTaskExists) || !errors.As(err, &e) || e.StatusCode != http.StatusNotFound || e.TraceID != "abc" {
+		t.Fatalf("get missing: %#v", err)
+	}
+
+	body, err := c.GetArtifact(ctx, "t1", "attempts/001-carbon/prompts/a b.md")
+	if err != nil {
+		t.Fatalf("get artifact: %v", err)
+	}
+	b, _ := io.ReadAll(body)
+	body.Close()
+	if string(b) != "hello" || gotRaw != "/v1/tasks/t1/artifacts/attempts/001-carbon/prompts/a%20b.md" {
+		t.Fatalf("artifact: %q via %q", b, gotRaw)
+	}
+
+	batch, err := c.BatchCancel(ctx, BatchRequest{Selector: "a"})
+	if err != nil || len(batch.Results) != 2 || batch.Results[0].Task.TaskID != "a" || len(batch.Failed()) != 1 {
+		t.Fatalf("batch cancel: %+v %v", batch, err)
+	}
+
+	// non-JSON error bodies are kept verbatim; POSTs are not retried
+	_, err = c.CleanupTask(ctx, "t1")
+	if !errors.As(err, &e) || e.Code != "" || e.StatusCode != http.StatusBadGateway || err.Error() != "request failed: 502 Bad Gateway: upstream down" {
+		t.Fatalf("cleanup: %v", err)
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	var gets, posts, lists atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/tasks", func(w http.ResponseWriter, r *http.Request) {
+		code := CodeBudgetExceeded
+		if lists.Add(1) > 1 {
+			code = "rate_limited"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_ = json.NewEncoder(w).Encode(Error{Code: code, Message: "slow down"})
+	})
+	mux.HandleFunc("GET /v1/tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if gets.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(Task{TaskID: r.PathValue("id")})
+	})
+	mux.HandleFunc("POST /v1/tasks", func(w http.ResponseWriter, r *http.Request) {
+		var req CreateTaskRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Prompt != "p" {
+			t.Errorf("retried body not replayed: %v %+v", err, req)
+		}
+		if posts.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(Task{TaskID: "t1"})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New(srv.URL, nil)
+	c.Retry = RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+	ctx := context.Background()
+
+	if task, err := c.GetTask(ctx, "t1"); err != nil || task.TaskID != "t1" || gets.Load() != 3 {
+		t.Fatalf("get: %v after %d tries", err, gets.Load())
+	}
+
+	// without an idempotency key a submit is tried once
+	if _, err := c.CreateTask(ctx, CreateTaskRequest{Prompt: "p"}, CreateTaskOptions{}); err == nil || posts.Load() != 1 {
+		t.Fatalf("create without key: %v after %d tries", err, posts.Load())
+	}
+	if _, err := c.CreateTask(ctx, CreateTaskRequest{Prompt: "p"}, CreateTaskOptions{IdempotencyKey: "k"}); err != nil || posts.Load() != 2 {
+		t.Fatalf("create with key: %v after %d tries", err, posts.Load())
+	}
+
+	// a spent budget is not retried, other 429s are
+	if _, err := c.ListTasks(ctx, ListTasksOptions{}); !errors.Is(err, ErrBudgetExceeded) || lists.Load() != 1 {
+		t.Fatalf("list over budget: %v after %d tries", err, lists.Load())
+	}
+	if _, err := c.ListTasks(ctx, ListTasksOptions{}); err == nil || lists.Load() != 4 {
+		t.Fatalf("list rate limited: %v after %d tries", err, lists.Load())
+	}
+
+	// retries give up after MaxAttempts
+	gets.Store(-10)
+	if _, err := c.GetTask(ctx, "t1"); !errors.As(err, new(*Error)) || gets.Load() != -7 {
+		t.Fatalf("expected error after 3 tries, got %v after %d", err, gets.Load()+10)
+	}
+}
+
+func TestClient_EventsAndWait(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/tasks/t1/events" {
+			http.NotFound(w, r)
+			return
+		}
+		enc := json.NewEncoder(w)
+		for i, typ := range []string{EventTaskCreated, EventAttemptStarted, EventAttemptFinished, EventTaskCompleted} {
+			_ = enc.Encode(Event{Seq: int64(i + 1), Type: typ, Task: Task{TaskID: "t1", Status: "running"}})
+		}
+	}))
+	defer srv.Close()
+	c := New(srv.URL, nil)
+
+	stream, err := c.Events(context.Background(), "t1", 0)
+	if err != nil {
+		t.Fatalf("events: %v", err)
+	}
+	var types []string
+	for {
+		e, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next: %v", err)
+		}
+		types = append(types, e.Type)
+	}
+	stream.Close()
+	if len(types) != 4 || types[3] != EventTaskCompleted {
+		t.Fatalf("unexpected events: %v", types)
+	}
+
+	task, err := c.Wait(context.Background(), "t1")
+	if err != nil || task.TaskID != "t1" {
+		t.Fatalf("wait: %+v %v", task, err)
+	}
+}
+
+func TestClient_StreamsOutliveTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/tasks/t1" {
+			time.Sleep(200 * time.Millisecond)
+			return
+		}
+		// a slow follow: lines keep coming after the client timeout
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "line %d\n", i)
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+	c := New(srv.URL, &http.Client{Timeout: 150 * time.Millisecond})
+	c.Retry = RetryPolicy{}
+
+	logs, err := c.Logs(context.Background(), "t1", LogsOptions{Follow: true})
+	if err != nil {
+		t.Fatalf("logs: %v", err)
+	}
+	b, err := io.ReadAll(logs)
+	logs.Close()
+	if err != nil || string(b) != "line 0\nline 1\nline 2\n" {
+		t.Fatalf("followed logs cut off: %q %v", b, err)
+	}
+
+	// ordinary calls keep the timeout
+	if _, err := c.GetTask(context.Background(), "t1"); err == nil {
+		t.Fatalf("expected GetTask to time out")
+	}
+}
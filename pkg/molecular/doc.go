@@ -0,0 +1,11 @@
+// Package molecular is the public Go API for Silicon, the molecular task
+// daemon: the /v1 request and response types, typed errors, and a Client
+// with retries and streaming helpers.
+//
+// The package is versioned with the HTTP API. Within v1, fields, methods
+// and error codes may be added, but existing ones keep their names, JSON
+// encoding and meaning.
+package molecular
+
+// APIVersion is the Silicon HTTP API version this package speaks.
+const APIVersion = "v1"
@@ -0,0 +1,96 @@
+package molecular
+
+import "fmt"
+
+// ErrorCode is a stable, machine-readable error identifier. Codes are part
+// of the API contract: new ones may be added, existing ones never change
+// meaning.
+type ErrorCode string
+
+const (
+	// CodeInvalidRequest: malformed JSON, missing or invalid fields. 400.
+	CodeInvalidRequest ErrorCode = "invalid_request"
+	// CodeRequestTooLarge: request body over the size limit. 413.
+	CodeRequestTooLarge ErrorCode = "request_too_large"
+	// CodeUnauthorized: missing or unknown bearer token. 401.
+	CodeUnauthorized ErrorCode = "unauthorized"
+	// CodeForbidden: the token's scope does not allow the request. 403.
+	CodeForbidden ErrorCode = "forbidden"
+	// CodeNotFound: unknown task, artifact or route. 404.
+	CodeNotFound ErrorCode = "not_found"
+	// CodeTaskExists: a task with the requested ID already exists. 409.
+	CodeTaskExists ErrorCode = "task_exists"
+	// CodeBranchExists: the requested branch already exists in the repo. 409.
+	CodeBranchExists ErrorCode = "branch_exists"
+	// CodeTaskNotRunning: the operation needs a running task. 409.
+	CodeTaskNotRunning ErrorCode = "task_not_running"
+	// CodeTaskRunning: the operation needs a finished task. 409.
+	CodeTaskRunning ErrorCode = "task_running"
+	// CodeNoWorktree: the task has no worktree, or it was cleaned up or
+	// handed to a retry. 409.
+	CodeNoWorktree ErrorCode = "no_worktree"
+	// CodeBudgetExceeded: a token, cost or time cap from the limits
+	// configuration was reached; details name the cap, its limit and the
+	// amount used. 429 when it refuses a submission.
+	CodeBudgetExceeded ErrorCode = "budget_exceeded"
+	// CodePromptFailed: the task's prompt could not be rendered, because a
+	// template is invalid or the prompt is over the size limit even after
+	// truncation. Only seen as the error of a failed task.
+	CodePromptFailed ErrorCode = "prompt_failed"
+	// CodeAgentFailed: the attempt's agent could not be started or exited
+	// non-zero. Only seen as the error of a failed task.
+	CodeAgentFailed ErrorCode = "agent_failed"
+	// CodeNotImplemented: the endpoint exists but is not implemented yet. 501.
+	CodeNotImplemented ErrorCode = "not_implemented"
+	// CodeInternal: an unexpected server-side failure. 500.
+	CodeInternal ErrorCode = "internal"
+)
+
+// Error is the JSON body of every non-2xx Silicon response, and the error
+// Client returns for them. Compare with errors.Is against the Err*
+// sentinels, which match on Code:
+//
+//	if errors.Is(err, molecular.ErrNotFound) { ... }
+type Error struct {
+	Code    ErrorCode      `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+	// TraceID identifies the request's trace, for finding it in Jaeger.
+	TraceID string `json:"trace_id,omitempty"`
+	// StatusCode is the response's HTTP status. It is set by Client and
+	// not part of the JSON body.
+	StatusCode int `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		// not an API error body, e.g. from a proxy in front of Silicon
+		return "request failed: " + e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Is reports whether target is an *Error with the same Code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code != "" && t.Code == e.Code
+}
+
+// Sentinels for errors.Is.
+var (
+	ErrInvalidRequest  = &Error{Code: CodeInvalidRequest}
+	ErrUnauthorized    = &Error{Code: CodeUnauthorized}
+	ErrForbidden       = &Error{Code: CodeForbidden}
+	ErrNotFound        = &Error{Code: CodeNotFound}
+	ErrTaskExists      = &Error{Code: CodeTaskExists}
+	ErrBranchExists    = &Error{Code: CodeBranchExists}
+	ErrTaskNotRunning  = &Error{Code: CodeTaskNotRunning}
+	ErrTaskRunning     = &Error{Code: CodeTaskRunning}
+	ErrNoWorktree      = &Error{Code: CodeNoWorktree}
+	ErrBudgetExceeded  = &Error{Code: CodeBudgetExceeded}
+	ErrPromptFailed    = &Error{Code: CodePromptFailed}
+	ErrAgentFailed     = &Error{Code: CodeAgentFailed}
+	ErrNotImplemented  = &Error{Code: CodeNotImplemented}
+	ErrInternal        = &Error{Code: CodeInternal}
+	ErrRequestTooLarge = &Error{Code: CodeRequestTooLarge}
+)
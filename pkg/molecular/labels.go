@@ -0,0 +1,155 @@
+package molecular
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Limits on task labels. Keys and values use the same character set so
+// they are safe in selectors, query strings and span attribute names.
+const (
+	MaxLabels         = 32
+	MaxLabelKeyLen    = 63
+	MaxLabelValueLen  = 63
+	MaxMetadataKeys   = 32
+	MaxMetadataKeyLen = 63
+)
+
+// ValidateLabels checks label keys and values: keys are 1-63 characters,
+// values 0-63, both drawn from letters, digits, '-', '_' and '.', and
+// starting and ending with a letter or digit.
+func ValidateLabels(labels map[string]string) error {
+	if len(labels) > MaxLabels {
+		return fmt.Errorf("at most %d labels allowed", MaxLabels)
+	}
+	for k, v := range labels {
+		if !validLabelToken(k, MaxLabelKeyLen) {
+			return fmt.Errorf("invalid label key %q", k)
+		}
+		if v != "" && !validLabelToken(v, MaxLabelValueLen) {
+			return fmt.Errorf("invalid value %q for label %q", v, k)
+		}
+	}
+	return nil
+}
+
+// ValidateMetadata checks metadata keys; values are free-form.
+func ValidateMetadata(md map[string]string) error {
+	if len(md) > MaxMetadataKeys {
+		return fmt.Errorf("at most %d metadata keys allowed", MaxMetadataKeys)
+	}
+	for k := range md {
+		if k == "" || len(k) > MaxMetadataKeyLen {
+			return fmt.Errorf("invalid metadata key %q", k)
+		}
+	}
+	return nil
+}
+
+func validLabelToken(s string, max int) bool {
+	if s == "" || len(s) > max {
+		return false
+	}
+	for i, r := range s {
+		alnum := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+		if !alnum && (i == 0 || i == len(s)-1 || (r != '-' && r != '_' && r != '.')) {
+			return false
+		}
+	}
+	return true
+}
+
+// Selector matches tasks by label. Its text form is a comma-separated
+// list of requirements, all of which must hold:
+//
+//	key=value, key==value  label equals value
+//	key!=value             label is missing or differs
+//	key                    label is present
+//	!key                   label is absent
+type Selector []Requirement
+
+// Requirement is one term of a Selector.
+type Requirement struct {
+	Key   string
+	Op    string // "=", "!=", "exists" or "!exists"
+	Value string
+}
+
+// ParseSelector parses the text form of a Selector. An empty string
+// selects everything.
+func ParseSelector(s string) (Selector, error) {
+	var sel Selector
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		var req Requirement
+		switch {
+		case strings.HasPrefix(term, "!") && !strings.Contains(term, "="):
+			req = Requirement{Key: strings.TrimSpace(term[1:]), Op: "!exists"}
+		case strings.Contains(term, "!="):
+			k, v, _ := strings.Cut(term, "!=")
+			req = Requirement{Key: strings.TrimSpace(k), Op: "!=", Value: strings.TrimSpace(v)}
+		case strings.Contains(term, "="):
+			k, v, _ := strings.Cut(term, "=")
+			req = Requirement{Key: strings.TrimSpace(k), Op: "=", Value: strings.TrimSpace(strings.TrimPrefix(v, "="))}
+		default:
+			req = Requirement{Key: term, Op: "exists"}
+		}
+		if !validLabelToken(req.Key, MaxLabelKeyLen) {
+			return nil, fmt.Errorf("invalid label key in selector term %q", term)
+		}
+		if req.Value != "" && !validLabelToken(req.Value, MaxLabelValueLen) {
+			return nil, fmt.Errorf("invalid label value in selector term %q", term)
+		}
+		sel = append(sel, req)
+	}
+	if len(sel) == 0 && strings.TrimSpace(s) != "" {
+		return nil, errors.New("empty selector")
+	}
+	return sel, nil
+}
+
+// Matches reports whether labels satisfy every requirement.
+func (sel Selector) Matches(labels map[string]string) bool {
+	for _, r := range sel {
+		v, ok := labels[r.Key]
+		switch r.Op {
+		case "=":
+			if !ok || v != r.Value {
+				return false
+			}
+		case "!=":
+			if ok && v == r.Value {
+				return false
+			}
+		case "exists":
+			if !ok {
+				return false
+			}
+		case "!exists":
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// String returns the text form of sel.
+func (sel Selector) String() string {
+	terms := make([]string, len(sel))
+	for i, r := range sel {
+		switch r.Op {
+		case "exists":
+			terms[i] = r.Key
+		case "!exists":
+			terms[i] = "!" + r.Key
+		default:
+			terms[i] = r.Key + r.Op + r.Value
+		}
+	}
+	return strings.Join(terms, ",")
+}
@@ -0,0 +1,55 @@
+package molecular
+
+import "testing"
+
+func TestValidateLabels(t *testing.T) {
+	ok := []map[string]string{nil, {"epic": "billing"}, {"team.owner": ""}, {"a": "b-c_d.e"}}
+	for _, l := range ok {
+		if err := ValidateLabels(l); err != nil {
+			t.Errorf("%v: %v", l, err)
+		}
+	}
+	bad := []map[string]string{{"": "x"}, {"-epic": "x"}, {"epic": "bill ing"}, {"epic": "x/y"}, {"ép": "x"}}
+	for _, l := range bad {
+		if err := ValidateLabels(l); err == nil {
+			t.Errorf("%v: expected error", l)
+		}
+	}
+	if err := ValidateMetadata(map[string]string{"": "x"}); err == nil {
+		t.Errorf("expected error for empty metadata key")
+	}
+}
+
+func TestSelector(t *testing.T) {
+	labels := map[string]string{"epic": "billing", "owner": "ana"}
+	cases := map[string]bool{
+		"":                          true,
+		"epic=billing":              true,
+		"epic==billing":             true,
+		"epic=billing, owner!=bob":  true,
+		"epic=search":               false,
+		"owner!=ana":                false,
+		"owner":                     true,
+		"!owner":                    false,
+		"!team":                     true,
+		"team!=x":                   true,
+		"epic=billing,owner=ana,!x": true,
+	}
+	for s, want := range cases {
+		sel, err := ParseSelector(s)
+		if err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+		if got := sel.Matches(labels); got != want {
+			t.Errorf("%q matches = %v, want %v", s, got, want)
+		}
+	}
+	if sel, _ := ParseSelector("epic==billing, !x ,owner"); sel.String() != "epic=billing,!x,owner" {
+		t.Errorf("String() = %q", sel.String())
+	}
+	for _, s := range []string{",", "a b=c", "epic=a=b", "=x", "!"} {
+		if _, err := ParseSelector(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package molecular
+
+// IdempotencyKeyHeader names the request header that makes POST /v1/tasks
+// safe to retry: a repeated key returns the task created by the first
+// request instead of creating another.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type TaskStatus string
+
+type Task struct {
+	TaskID           string          `json:"task_id"`
+	Prompt           string          `json:"prompt"`
+	Status           TaskStatus      `json:"status"`
+	Phase            string          `json:"phase"`
+	CreatedAt        string          `json:"created_at"`
+	UpdatedAt        string          `json:"updated_at"`
+	CarbonBudget     int             `json:"carbon_budget"`
+	HeliumBudget     int             `json:"helium_budget"`
+	ReviewBudget     int             `json:"review_budget"`
+	PromptTemplate   *PromptTemplate `json:"prompt_template,omitempty"`
+	ArtifactsRoot    string          `json:"artifacts_root"`
+	WorktreePath     string          `json:"worktree_path"`
+	RepoPath         string          `json:"repo_path,omitempty"`
+	BaseRef          string          `json:"base_ref,omitempty"`
+	BaseCommit       string          `json:"base_commit"`
+	BranchName       string          `json:"branch_name,omitempty"`
+	CurrentAttemptID *int64          `json:"current_attempt_id,omitempty"`
+	LatestAttempt    *Attempt        `json:"latest_attempt,omitempty"`
+	// Labels group tasks for selectors; see ValidateLabels.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Metadata is free-form and never interpreted by Silicon.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// RetryOf is the ID of the task this one retries.
+	RetryOf string `json:"retry_of,omitempty"`
+	// ClaimedBy is the retry that took over this task's worktree and
+	// branch. The worktree stays readable here, but cleanup and further
+	// retries of this task leave it to the retry.
+	ClaimedBy string `json:"claimed_by,omitempty"`
+	// Feedback lists the human notes added while the task ran, oldest
+	// first.
+	Feedback []Feedback `json:"feedback,omitempty"`
+	// Usage totals the usage reported by the task's attempts, and
+	// UsageByModel breaks it down by model, sorted by model name.
+	Usage        *Usage  `json:"usage,omitempty"`
+	UsageByModel []Usage `json:"usage_by_model,omitempty"`
+	// Error says why a task with status "failed" or "budget_exceeded"
+	// stopped.
+	Error *Error `json:"error,omitempty"`
+}
+
+type CreateTaskRequest struct {
+	// TaskID is optional; Silicon generates one from the prompt when empty.
+	TaskID string `json:"task_id,omitempty"`
+	Prompt string `json:"prompt"`
+	// IfNotExists makes a submit for an existing TaskID return that task
+	// (200) instead of failing with 409.
+	IfNotExists bool `json:"if_not_exists,omitempty"`
+	// RepoPath is the absolute path of the local git repository to create
+	// the task's worktree in. BaseRef (default HEAD) and BranchName
+	// (default molecular/<task_id>) require it.
+	RepoPath   string `json:"repo_path,omitempty"`
+	BaseRef    string `json:"base_ref,omitempty"`
+	BranchName string `json:"branch_name,omitempty"`
+	// PromptTemplate records how Prompt was rendered, when it came from a
+	// template, so the task can be reproduced.
+	PromptTemplate *PromptTemplate `json:"prompt_template,omitempty"`
+	// Labels and Metadata are copied onto the task.
+	Labels   map[string]string `json:"labels,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// RetryRequest tunes POST /v1/tasks/{id}/retry. The zero value reruns
+// the task in its existing worktree and branch with the same budgets.
+type RetryRequest struct {
+	// Fresh checks out a new worktree and branch from the original base
+	// ref instead of continuing in the original's.
+	Fresh bool `json:"fresh,omitempty"`
+	// Budgets overrides attempt budgets by role: "carbon", "helium" or
+	// "review".
+	Budgets map[string]int `json:"budgets,omitempty"`
+}
+
+// PromptTemplate describes the template a task's prompt was rendered from.
+type PromptTemplate struct {
+	Name   string            `json:"name"`
+	Vars   map[string]string `json:"vars,omitempty"`
+	SHA256 string            `json:"sha256"`
+	Source string            `json:"source"`
+}
+
+type Attempt struct {
+	ID           int64  `json:"id"`
+	TaskID       string `json:"task_id"`
+	Role         string `json:"role"`
+	AttemptNum   int64  `json:"attempt_num"`
+	Status       string `json:"status"`
+	StartedAt    string `json:"started_at"`
+	FinishedAt   string `json:"finished_at"`
+	ArtifactsDir string `json:"artifacts_dir"`
+	ErrorSummary string `json:"error_summary"`
+	// Feedback is the human feedback included in this attempt's prompt.
+	Feedback []Feedback `json:"feedback,omitempty"`
+	// Usage is what the attempt's agent reported consuming, if anything.
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// Usage counts the tokens and cost of agent work. Model is empty on
+// totals that span models.
+type Usage struct {
+	Model        string  `json:"model,omitempty"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// Add adds o's counts and cost to u. The model is left alone.
+func (u *Usage) Add(o Usage) {
+	u.InputTokens += o.InputTokens
+	u.OutputTokens += o.OutputTokens
+	u.CostUSD += o.CostUSD
+}
+
+// FeedbackRequest is the body of POST /v1/tasks/{id}/feedback.
+type FeedbackRequest struct {
+	Message string `json:"message"`
+	// Author optionally names who gave the feedback.
+	Author string `json:"author,omitempty"`
+}
+
+// Feedback is a human note on a running task. Silicon adds it to the
+// prompt of the task's next Carbon attempt.
+type Feedback struct {
+	Message   string `json:"message"`
+	Author    string `json:"author,omitempty"`
+	CreatedAt string `json:"created_at"`
+	// Attempt is the number of the attempt whose prompt included the
+	// note; zero while it is pending.
+	Attempt int64 `json:"attempt,omitempty"`
+	// TakenBy is the retry that carried the note over while it was
+	// pending; the note is no longer pending here.
+	TakenBy string `json:"taken_by,omitempty"`
+}
+
+// Pending reports whether no attempt has included f and no retry has
+// taken it.
+func (f Feedback) Pending() bool {
+	return f.Attempt == 0 && f.TakenBy == ""
+}
+
+// Artifact describes a single file stored under a task's artifacts root.
+type Artifact struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	SHA256  string `json:"sha256"`
+	ModTime string `json:"mod_time"`
+}
+
+// CleanupResult reports what POST /v1/tasks/{id}/cleanup removed.
+type CleanupResult struct {
+	TaskID    string `json:"task_id,omitempty"`
+	Artifacts bool   `json:"artifacts"`
+	Worktree  bool   `json:"worktree"`
+}
+
+// Event types streamed by GET /v1/tasks/{id}/events.
+const (
+	EventTaskCreated     = "task.created"
+	EventAttemptStarted  = "attempt.started"
+	EventAttemptFinished = "attempt.finished"
+	EventTaskCompleted   = "task.completed"
+	EventTaskCancelled   = "task.cancelled"
+	EventTaskFailed      = "task.failed"
+)
+
+// Event is one entry in a task's lifecycle stream. Seq increases by one per
+// event within a task; pass the last seen Seq as "after" to resume.
+type Event struct {
+	Seq  int64  `json:"seq"`
+	Time string `json:"time"`
+	Type string `json:"type"`
+	// Task is a snapshot of the task right after the event.
+	Task Task `json:"task"`
+}
+
+// Terminal reports whether e ends the task's event stream.
+func (e Event) Terminal() bool {
+	return e.Type == EventTaskCompleted || e.Type == EventTaskCancelled || e.Type == EventTaskFailed
+}
+
+// TaskList is one page of GET /v1/tasks.
+type TaskList struct {
+	Tasks []Task `json:"tasks"`
+	// NextCursor fetches the following page; empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// BatchRequest selects the tasks for POST /v1/tasks:batchCancel,
+// :batchCleanup and :batchRetry: either TaskIDs, or every task matching
+// Selector and Status. All must be set to select every task without a
+// filter.
+type BatchRequest struct {
+	TaskIDs  []string `json:"task_ids,omitempty"`
+	Selector string   `json:"selector,omitempty"`
+	Status   string   `json:"status,omitempty"`
+	All      bool     `json:"all,omitempty"`
+}
+
+// BatchResult is the outcome of a batch operation for one task. Exactly
+// one of Task, Cleanup and Error is set: Task is the cancelled task or the
+// new retry task, Cleanup what was removed.
+type BatchResult struct {
+	TaskID  string         `json:"task_id"`
+	Task    *Task          `json:"task,omitempty"`
+	Cleanup *CleanupResult `json:"cleanup,omitempty"`
+	Error   *Error         `json:"error,omitempty"`
+}
+
+// BatchResponse lists per-task results in task ID order.
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// Failed returns the results that carry an error.
+func (b *BatchResponse) Failed() []BatchResult {
+	var failed []BatchResult
+	for _, r := range b.Results {
+		if r.Error != nil {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}